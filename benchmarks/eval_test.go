@@ -2,69 +2,22 @@ package benchmarks
 
 import (
 	"context"
-	"encoding/json"
-	"os"
 	"sort"
 	"strings"
 	"testing"
 
+	"github.com/mdombrov-33/go-promptguard/dataset"
 	"github.com/mdombrov-33/go-promptguard/detector"
 )
 
-// Dataset types
-type Sample struct {
-	ID       string `json:"id"`
-	Input    string `json:"input"`
-	Label    string `json:"label"`    // "attack" or "safe"
-	Category string `json:"category"` // e.g. "role_injection"
-	Notes    string `json:"notes"`
-}
-
-type Dataset struct {
-	Version string   `json:"version"`
-	Samples []Sample `json:"samples"`
-}
-
 // EvalResult pairs a sample with the detector result so we never call Detect twice.
 type EvalResult struct {
-	Sample Sample
+	Sample dataset.Sample
 	Result detector.Result
 }
 
-// Confusion matrix counts and derived metrics
-type Counts struct {
-	TP, FP, TN, FN int
-}
-
-func (c Counts) Precision() float64 {
-	if c.TP+c.FP == 0 {
-		return 0
-	}
-	return float64(c.TP) / float64(c.TP+c.FP) * 100
-}
-
-func (c Counts) Recall() float64 {
-	if c.TP+c.FN == 0 {
-		return 0
-	}
-	return float64(c.TP) / float64(c.TP+c.FN) * 100
-}
-
-func (c Counts) F1() float64 {
-	p, r := c.Precision(), c.Recall()
-	if p+r == 0 {
-		return 0
-	}
-	return 2 * (p * r) / (p + r)
-}
-
-func (c Counts) Accuracy() float64 {
-	total := c.TP + c.FP + c.TN + c.FN
-	if total == 0 {
-		return 0
-	}
-	return float64(c.TP+c.TN) / float64(total) * 100
-}
+// Counts is the confusion matrix shared with the CLI's eval command.
+type Counts = detector.ConfusionMatrix
 
 // Shared category lists used by multiple tests.
 var (
@@ -73,22 +26,18 @@ var (
 )
 
 // Helpers
-func loadDataset(t *testing.T, path string) []Sample {
+func loadDataset(t *testing.T, path string) []dataset.Sample {
 	t.Helper()
-	data, err := os.ReadFile(path)
+	samples, err := dataset.Load(path)
 	if err != nil {
-		t.Fatalf("failed to read dataset %s: %v", path, err)
-	}
-	var ds Dataset
-	if err := json.Unmarshal(data, &ds); err != nil {
-		t.Fatalf("failed to parse dataset %s: %v", path, err)
+		t.Fatalf("%v", err)
 	}
-	return ds.Samples
+	return samples
 }
 
 // evaluate runs all samples through the guard once and returns counts + per-category
 // breakdown + slices of false positives and false negatives with their cached results.
-func evaluate(ctx context.Context, guard *detector.MultiDetector, samples []Sample) (Counts, map[string]Counts, []EvalResult, []EvalResult) {
+func evaluate(ctx context.Context, guard *detector.MultiDetector, samples []dataset.Sample) (Counts, map[string]Counts, []EvalResult, []EvalResult) {
 	var overall Counts
 	perCategory := make(map[string]Counts)
 	var falsePositives, falseNegatives []EvalResult
@@ -100,23 +49,16 @@ func evaluate(ctx context.Context, guard *detector.MultiDetector, samples []Samp
 		er := EvalResult{Sample: s, Result: result}
 
 		c := perCategory[s.Category]
+		overall.Add(isAttack, shouldBeAttack)
+		c.Add(isAttack, shouldBeAttack)
+		perCategory[s.Category] = c
+
 		switch {
-		case isAttack && shouldBeAttack:
-			overall.TP++
-			c.TP++
 		case isAttack && !shouldBeAttack:
-			overall.FP++
-			c.FP++
 			falsePositives = append(falsePositives, er)
-		case !isAttack && !shouldBeAttack:
-			overall.TN++
-			c.TN++
 		case !isAttack && shouldBeAttack:
-			overall.FN++
-			c.FN++
 			falseNegatives = append(falseNegatives, er)
 		}
-		perCategory[s.Category] = c
 	}
 
 	return overall, perCategory, falsePositives, falseNegatives
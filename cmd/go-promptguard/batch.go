@@ -31,6 +31,9 @@ type BatchSummary struct {
 	Duration   time.Duration
 }
 
+// batchChunkSize is how many lines ProcessBatch hands to DetectBatch at once.
+const batchChunkSize = 20
+
 func ProcessBatch(filePath string, guard *detector.MultiDetector, progressChan chan<- int) (*BatchSummary, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
@@ -51,36 +54,49 @@ func ProcessBatch(filePath string, guard *detector.MultiDetector, progressChan c
 	startTime := time.Now()
 	ctx := context.Background()
 
-	for i, input := range inputs {
-		input = strings.TrimSpace(input)
-		if input == "" {
-			continue
-		}
-
-		result := guard.Detect(ctx, input)
-
-		batchResult := BatchResult{
-			Input:       input,
-			Result:      result,
-			ProcessedAt: time.Now(),
-		}
-		summary.Results = append(summary.Results, batchResult)
-
-		if result.Safe {
-			summary.Safe++
-		} else {
-			summary.Unsafe++
-			if result.RiskScore >= 0.9 {
-				summary.HighRisk++
-			} else if result.RiskScore >= 0.7 {
-				summary.MediumRisk++
+	// Lines go to the guard in chunks so an LLM judge that supports batching
+	// classifies a whole chunk in one request
+	var chunk []string
+	done := 0
+	flush := func(lastLine int) {
+		for i, result := range guard.DetectBatch(ctx, chunk) {
+			summary.Results = append(summary.Results, BatchResult{
+				Input:       chunk[i],
+				Result:      result,
+				ProcessedAt: time.Now(),
+			})
+
+			if result.Safe {
+				summary.Safe++
 			} else {
-				summary.LowRisk++
+				summary.Unsafe++
+				if result.RiskScore >= 0.9 {
+					summary.HighRisk++
+				} else if result.RiskScore >= 0.7 {
+					summary.MediumRisk++
+				} else {
+					summary.LowRisk++
+				}
 			}
 		}
+		chunk = chunk[:0]
 
+		// One tick per input line, blank lines included
 		if progressChan != nil {
-			progressChan <- i + 1
+			for line := done + 1; line <= lastLine; line++ {
+				progressChan <- line
+			}
+		}
+		done = lastLine
+	}
+
+	for i, input := range inputs {
+		input = strings.TrimSpace(input)
+		if input != "" {
+			chunk = append(chunk, input)
+		}
+		if len(chunk) == batchChunkSize || i == len(inputs)-1 {
+			flush(i + 1)
 		}
 	}
 
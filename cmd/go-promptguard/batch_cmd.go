@@ -13,6 +13,7 @@ import (
 var (
 	batchThreshold float64
 	batchOutput    string
+	batchModel     string
 )
 
 var batchCmd = &cobra.Command{
@@ -41,6 +42,7 @@ func init() {
 
 	batchCmd.Flags().Float64VarP(&batchThreshold, "threshold", "t", 0.7, "Risk threshold (0.0-1.0)")
 	batchCmd.Flags().StringVarP(&batchOutput, "output", "o", "", "Output file (JSON or CSV)")
+	batchCmd.Flags().StringVar(&batchModel, "model", "", "Score with a trained model (see 'train')")
 }
 
 func runBatch(cmd *cobra.Command, args []string) {
@@ -53,9 +55,18 @@ func runBatch(cmd *cobra.Command, args []string) {
 
 	inputFile := args[0]
 
-	guard := detector.New(
+	opts := []detector.Option{
 		detector.WithThreshold(batchThreshold),
-	)
+	}
+	if batchModel != "" {
+		model, err := detector.LoadModel(batchModel)
+		if err != nil {
+			color.Red("Error loading model: %v", err)
+			os.Exit(1)
+		}
+		opts = append(opts, detector.WithClassifier(model))
+	}
+	guard := detector.New(opts...)
 
 	color.Cyan("📦 Processing batch file: %s", inputFile)
 	fmt.Println()
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/mdombrov-33/go-promptguard/detector"
+	"github.com/spf13/cobra"
+)
+
+var (
+	calibrateAttacks   []string
+	calibrateBenign    []string
+	calibrateStep      float64
+	calibrateTargetFPR float64
+	calibrateFit       string
+	calibrateOutput    string
+	calibrateCurve     string
+	calibrateModel     string
+	calibrateData      datasetFlags
+)
+
+var calibrateCmd = &cobra.Command{
+	Use:   "calibrate",
+	Short: "Pick a threshold and calibrate scores from labeled data",
+	Long: `Sweep thresholds over attack and benign datasets (JSON, JSONL or CSV, see
+'eval --help' for column mapping), print precision/recall/FPR at each threshold and
+recommend one for a target false-positive rate.
+
+Optionally fit a score-to-probability mapping (Platt or isotonic) that the
+library can load with detector.LoadCalibration so RiskScore becomes a
+calibrated probability.
+
+Examples:
+  # Sweep and recommend a threshold for 1% FPR
+  go-promptguard calibrate --attacks attacks.json --benign benign.json --target-fpr 0.01
+
+  # Export the curve
+  go-promptguard calibrate --attacks attacks.json --benign benign.json --curve curve.csv
+  go-promptguard calibrate --attacks attacks.json --benign benign.json --curve curve.json
+
+  # Fit an isotonic mapping
+  go-promptguard calibrate --attacks attacks.json --benign benign.json --fit isotonic --output calibration.json`,
+	Run: runCalibrate,
+}
+
+func init() {
+	rootCmd.AddCommand(calibrateCmd)
+
+	calibrateCmd.Flags().StringSliceVar(&calibrateAttacks, "attacks", nil, "Attack dataset(s) (JSON, JSONL or CSV)")
+	calibrateCmd.Flags().StringSliceVar(&calibrateBenign, "benign", nil, "Benign dataset(s) (JSON, JSONL or CSV)")
+	calibrateCmd.Flags().Float64Var(&calibrateStep, "step", 0.05, "Threshold sweep step")
+	calibrateCmd.Flags().Float64Var(&calibrateTargetFPR, "target-fpr", 0.05, "Target false-positive rate (0.0-1.0)")
+	calibrateCmd.Flags().StringVar(&calibrateFit, "fit", "", "Fit a score-to-probability mapping: platt or isotonic")
+	calibrateCmd.Flags().StringVarP(&calibrateOutput, "output", "o", "calibration.json", "Where to save the fitted mapping")
+	calibrateCmd.Flags().StringVar(&calibrateCurve, "curve", "", "Export the threshold curve (CSV or JSON)")
+	calibrateCmd.Flags().StringVar(&calibrateModel, "model", "", "Score with a trained model (see 'train')")
+	addDatasetFlags(calibrateCmd, &calibrateData)
+}
+
+func runCalibrate(cmd *cobra.Command, args []string) {
+	if len(calibrateAttacks) == 0 || len(calibrateBenign) == 0 {
+		color.Red("Error: both --attacks and --benign are required")
+		fmt.Println("\nUsage: go-promptguard calibrate --attacks attacks.json --benign benign.json")
+		os.Exit(1)
+	}
+
+	attacks, err := calibrateData.load(calibrateAttacks)
+	if err != nil {
+		color.Red("Error loading attacks: %v", err)
+		os.Exit(1)
+	}
+	benign, err := calibrateData.load(calibrateBenign)
+	if err != nil {
+		color.Red("Error loading benign inputs: %v", err)
+		os.Exit(1)
+	}
+
+	opts := []detector.Option{}
+	if calibrateModel != "" {
+		model, err := detector.LoadModel(calibrateModel)
+		if err != nil {
+			color.Red("Error loading model: %v", err)
+			os.Exit(1)
+		}
+		opts = append(opts, detector.WithClassifier(model))
+	}
+	guard := detector.New(opts...)
+	ctx := context.Background()
+
+	// Samples from --attacks count as attacks and --benign as safe, regardless of their label field.
+	scores := make([]float64, 0, len(attacks)+len(benign))
+	labels := make([]bool, 0, len(attacks)+len(benign))
+	for _, s := range attacks {
+		scores = append(scores, guard.Detect(ctx, s.Input).RiskScore)
+		labels = append(labels, true)
+	}
+	for _, s := range benign {
+		scores = append(scores, guard.Detect(ctx, s.Input).RiskScore)
+		labels = append(labels, false)
+	}
+
+	points := detector.SweepThresholds(scores, labels, calibrateStep)
+
+	color.Cyan("📈 Threshold sweep (%d attacks, %d benign)", len(attacks), len(benign))
+	fmt.Println()
+	fmt.Printf("  %-10s  %-10s  %-10s  %-10s  %-10s  %-5s  %-5s\n", "Threshold", "Precision", "Recall", "FPR", "F1", "FP", "FN")
+	fmt.Printf("  %s\n", strings.Repeat("-", 70))
+	for _, p := range points {
+		fmt.Printf("  %-10.2f  %-10s  %-10s  %-10s  %-10s  %-5d  %-5d\n",
+			p.Threshold, percent(p.Precision), percent(p.Recall), percent(p.FPR), percent(p.F1), p.FP, p.FN)
+	}
+	fmt.Println()
+
+	if best, ok := detector.RecommendThreshold(points, calibrateTargetFPR); ok {
+		color.Green("✓ Recommended threshold for FPR <= %s: %.2f (recall %s, precision %s)",
+			percent(calibrateTargetFPR), best.Threshold, percent(best.Recall), percent(best.Precision))
+	} else {
+		color.Yellow("⚠️  No threshold reaches FPR <= %s", percent(calibrateTargetFPR))
+	}
+	fmt.Println()
+
+	if calibrateCurve != "" {
+		if err := exportCurve(points, calibrateCurve); err != nil {
+			color.Red("Error saving curve: %v", err)
+			os.Exit(1)
+		}
+		color.Green("✓ Curve saved to: %s", calibrateCurve)
+		fmt.Println()
+	}
+
+	if calibrateFit != "" {
+		cal, err := detector.FitCalibration(calibrateFit, scores, labels)
+		if err != nil {
+			color.Red("Error fitting calibration: %v", err)
+			os.Exit(1)
+		}
+		if err := cal.Save(calibrateOutput); err != nil {
+			color.Red("Error saving calibration: %v", err)
+			os.Exit(1)
+		}
+		color.Green("✓ %s calibration saved to: %s", calibrateFit, calibrateOutput)
+		fmt.Println()
+	}
+}
+
+func exportCurve(points []detector.ThresholdPoint, outputPath string) error {
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if strings.HasSuffix(strings.ToLower(outputPath), ".json") {
+		encoder := json.NewEncoder(file)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(points)
+	}
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	header := []string{"Threshold", "TP", "FP", "TN", "FN", "Precision", "Recall", "FPR", "F1"}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for _, p := range points {
+		row := []string{
+			fmt.Sprintf("%.2f", p.Threshold),
+			fmt.Sprintf("%d", p.TP),
+			fmt.Sprintf("%d", p.FP),
+			fmt.Sprintf("%d", p.TN),
+			fmt.Sprintf("%d", p.FN),
+			fmt.Sprintf("%.4f", p.Precision),
+			fmt.Sprintf("%.4f", p.Recall),
+			fmt.Sprintf("%.4f", p.FPR),
+			fmt.Sprintf("%.4f", p.F1),
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func percent(f float64) string {
+	return fmt.Sprintf("%.1f%%", f*100)
+}
@@ -24,6 +24,7 @@ var (
 	noEntropy      bool
 	noPerplexity   bool
 	noTokenAnomaly bool
+	modelPath      string
 )
 
 var checkCmd = &cobra.Command{
@@ -61,6 +62,7 @@ func init() {
 	checkCmd.Flags().BoolVar(&noEntropy, "no-entropy", false, "Disable entropy detector")
 	checkCmd.Flags().BoolVar(&noPerplexity, "no-perplexity", false, "Disable perplexity detector")
 	checkCmd.Flags().BoolVar(&noTokenAnomaly, "no-token-anomaly", false, "Disable token anomaly detector")
+	checkCmd.Flags().StringVar(&modelPath, "model", "", "Score with a trained model (see 'train')")
 }
 
 func runCheck(cmd *cobra.Command, args []string) {
@@ -72,6 +74,18 @@ func runCheck(cmd *cobra.Command, args []string) {
 		detector.WithPerplexity(!noPerplexity),
 		detector.WithTokenAnomaly(!noTokenAnomaly),
 	}
+	if modelPath != "" {
+		if noEntropy || noPerplexity || noTokenAnomaly {
+			color.Red("Error: --model cannot be combined with --no-entropy, --no-perplexity or --no-token-anomaly: the model was trained with every detector enabled")
+			os.Exit(1)
+		}
+		model, err := detector.LoadModel(modelPath)
+		if err != nil {
+			color.Red("Error loading model: %v", err)
+			os.Exit(1)
+		}
+		opts = append(opts, detector.WithClassifier(model))
+	}
 	guard := detector.New(opts...)
 
 	var input string
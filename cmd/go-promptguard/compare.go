@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mdombrov-33/go-promptguard/dataset"
+	"github.com/mdombrov-33/go-promptguard/detector"
+)
+
+// VerdictChange is a sample whose verdict differs between the two sides.
+type VerdictChange struct {
+	ID         string  `json:"id"`
+	Category   string  `json:"category"`
+	Label      string  `json:"label"`
+	Input      string  `json:"input"`
+	BaseScore  float64 `json:"base_score"`
+	CandScore  float64 `json:"candidate_score"`
+	BaseSafe   bool    `json:"base_safe"`
+	CandSafe   bool    `json:"candidate_safe"`
+	Regression bool    `json:"regression"` // true if the candidate got this sample wrong and the base didn't
+}
+
+// CategoryDelta compares average scores and recall/FPR for one sample category.
+type CategoryDelta struct {
+	Samples        int     `json:"samples"`
+	BaseMeanScore  float64 `json:"base_mean_score"`
+	CandMeanScore  float64 `json:"candidate_mean_score"`
+	ScoreDelta     float64 `json:"score_delta"`
+	BaseMetrics    Metrics `json:"base"`
+	CandMetrics    Metrics `json:"candidate"`
+	ChangedVerdict int     `json:"changed_verdicts"`
+}
+
+// CompareReport is the machine-readable result of a comparison run.
+type CompareReport struct {
+	GeneratedAt time.Time                `json:"generated_at"`
+	Datasets    []string                 `json:"datasets"`
+	Base        string                   `json:"base"`
+	Candidate   string                   `json:"candidate"`
+	Compared    int                      `json:"compared"`
+	Missing     int                      `json:"missing"` // samples absent from a batch result file
+	BaseMetrics Metrics                  `json:"base_metrics"`
+	CandMetrics Metrics                  `json:"candidate_metrics"`
+	Categories  map[string]CategoryDelta `json:"categories"`
+	Changes     []VerdictChange          `json:"changes"`
+	RecallDrop  float64                  `json:"recall_drop"` // percentage points, positive = worse
+}
+
+// compareSide produces a result for every sample, either by running a guard or
+// by looking the input up in a saved batch result file.
+type compareSide struct {
+	name  string
+	guard *detector.MultiDetector
+	saved map[string]detector.Result
+}
+
+// loadCompareSide builds a side from a path: a batch result file written by
+// ExportResults (JSON) or a config file. An empty path means the default config.
+// A file with a Results field must be a valid batch result file; any other
+// file must hold only config fields, so a mangled result file is not mistaken
+// for a config that changes nothing.
+func loadCompareSide(path string, spec guardSpec) (*compareSide, error) {
+	side := &compareSide{name: path}
+	if path == "" {
+		side.name = "default config"
+	}
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return nil, fmt.Errorf("%s is neither a config nor a batch result file: %w", path, err)
+		}
+		if hasField(fields, "results") {
+			var summary BatchSummary
+			if err := json.Unmarshal(data, &summary); err != nil {
+				return nil, fmt.Errorf("failed to parse batch results %s: %w", path, err)
+			}
+			if summary.Results == nil {
+				return nil, fmt.Errorf("batch result file %s has no results", path)
+			}
+			side.saved = make(map[string]detector.Result, len(summary.Results))
+			for _, r := range summary.Results {
+				side.saved[strings.TrimSpace(r.Input)] = r.Result
+			}
+			return side, nil
+		}
+
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		cfg := defaultSavedConfig()
+		if err := dec.Decode(&cfg); err != nil {
+			return nil, fmt.Errorf("%s is not a config file: %w", path, err)
+		}
+		spec.config = path
+	}
+
+	guard, _, err := buildGuard(spec)
+	if err != nil {
+		return nil, err
+	}
+	side.guard = guard
+	return side, nil
+}
+
+// hasField reports whether fields has name, ignoring case like encoding/json.
+func hasField(fields map[string]json.RawMessage, name string) bool {
+	for k := range fields {
+		if strings.EqualFold(k, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *compareSide) result(ctx context.Context, input string) (detector.Result, bool) {
+	if s.guard != nil {
+		return s.guard.Detect(ctx, input), true
+	}
+	r, ok := s.saved[strings.TrimSpace(input)]
+	return r, ok
+}
+
+// Compare scores every labeled sample on both sides and reports what changed.
+func Compare(ctx context.Context, base, cand *compareSide, samples []dataset.Sample) (*CompareReport, error) {
+	report := &CompareReport{
+		GeneratedAt: time.Now().UTC(),
+		Base:        base.name,
+		Candidate:   cand.name,
+		Categories:  make(map[string]CategoryDelta),
+		Changes:     []VerdictChange{},
+	}
+
+	var baseResults, candResults []EvalResult
+	for _, s := range samples {
+		if !s.Labeled() {
+			continue
+		}
+
+		b, okB := base.result(ctx, s.Input)
+		c, okC := cand.result(ctx, s.Input)
+		if !okB || !okC {
+			report.Missing++
+			continue
+		}
+
+		baseResults = append(baseResults, EvalResult{Sample: s, Result: b})
+		candResults = append(candResults, EvalResult{Sample: s, Result: c})
+
+		delta := report.Categories[s.Category]
+		delta.Samples++
+		delta.BaseMeanScore += b.RiskScore
+		delta.CandMeanScore += c.RiskScore
+
+		if b.Safe != c.Safe {
+			delta.ChangedVerdict++
+			attack := s.Label == "attack"
+			report.Changes = append(report.Changes, VerdictChange{
+				ID:         s.ID,
+				Category:   s.Category,
+				Label:      s.Label,
+				Input:      s.Input,
+				BaseScore:  b.RiskScore,
+				CandScore:  c.RiskScore,
+				BaseSafe:   b.Safe,
+				CandSafe:   c.Safe,
+				Regression: c.Safe == attack,
+			})
+		}
+		report.Categories[s.Category] = delta
+	}
+
+	if len(baseResults) == 0 {
+		return nil, fmt.Errorf("no labeled samples could be compared (%d missing from result files)", report.Missing)
+	}
+	report.Compared = len(baseResults)
+
+	baseReport, candReport := summarize(baseResults), summarize(candResults)
+	report.BaseMetrics = baseReport.Overall
+	report.CandMetrics = candReport.Overall
+	report.RecallDrop = baseReport.Overall.Recall - candReport.Overall.Recall
+
+	for cat, delta := range report.Categories {
+		delta.BaseMeanScore /= float64(delta.Samples)
+		delta.CandMeanScore /= float64(delta.Samples)
+		delta.ScoreDelta = delta.CandMeanScore - delta.BaseMeanScore
+		delta.BaseMetrics = baseReport.Categories[cat]
+		delta.CandMetrics = candReport.Categories[cat]
+		report.Categories[cat] = delta
+	}
+
+	return report, nil
+}
+
+// Gate returns why the candidate fails the comparison: attack recall dropped
+// by more than tolerance points, or samples were missing from a result file
+// (unless allowMissing), which would leave them out of the recall.
+func (r *CompareReport) Gate(tolerance float64, allowMissing bool) error {
+	if r.Missing > 0 && !allowMissing {
+		return fmt.Errorf("%d samples are missing from a result file", r.Missing)
+	}
+	if r.RecallDrop > tolerance {
+		return fmt.Errorf("attack recall dropped %.1f points (tolerance %.1f)", r.RecallDrop, tolerance)
+	}
+	return nil
+}
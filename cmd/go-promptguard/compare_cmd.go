@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var (
+	compareBase      string
+	compareCandidate string
+	compareTolerance float64
+	compareMissing   bool
+	compareReport    string
+	compareData      datasetFlags
+)
+
+var compareCmd = &cobra.Command{
+	Use:   "compare [dataset...]",
+	Short: "Compare two configurations or result files on the same dataset",
+	Long: `Run two configurations over the same labeled dataset and report which
+inputs changed verdict, how scores moved per category and the net metric
+change. Exits with code 1 if attack recall drops by more than --tolerance
+percentage points, so it can gate config changes in CI. Samples missing from
+a batch result file also fail the run unless --allow-missing is set.
+
+--base and --candidate accept either a config file (format saved by the TUI)
+or a JSON batch result file written by 'batch --output results.json'.
+An omitted --base means the default configuration.
+
+Examples:
+  # Default config vs a tuned one
+  go-promptguard compare attacks.json benign.json --candidate tuned.json
+
+  # Two saved batch runs, allow up to 1 point of recall loss
+  go-promptguard compare data.jsonl --base before.json --candidate after.json --tolerance 1
+
+  # Write a report
+  go-promptguard compare data.csv --base a.json --candidate b.json --report diff.json`,
+	Run: runCompare,
+}
+
+func init() {
+	rootCmd.AddCommand(compareCmd)
+
+	compareCmd.Flags().StringVar(&compareBase, "base", "", "Baseline config or batch result file (default config if empty)")
+	compareCmd.Flags().StringVar(&compareCandidate, "candidate", "", "Candidate config or batch result file")
+	compareCmd.Flags().Float64Var(&compareTolerance, "tolerance", 0, "Allowed attack recall drop in percentage points")
+	compareCmd.Flags().BoolVar(&compareMissing, "allow-missing", false, "Compare only the samples found in both result files")
+	compareCmd.Flags().StringVarP(&compareReport, "report", "o", "", "Write a JSON report to this file")
+	addDatasetFlags(compareCmd, &compareData)
+}
+
+func runCompare(cmd *cobra.Command, args []string) {
+	if len(args) == 0 {
+		color.Red("Error: no dataset provided")
+		fmt.Println("\nUsage: go-promptguard compare [dataset...] --candidate config.json")
+		os.Exit(1)
+	}
+	if compareCandidate == "" {
+		color.Red("Error: --candidate is required")
+		os.Exit(1)
+	}
+
+	samples, err := compareData.load(args)
+	if err != nil {
+		color.Red("Error loading dataset: %v", err)
+		os.Exit(1)
+	}
+
+	spec := guardSpec{threshold: 0.7, llmMode: "conditional"}
+	base, err := loadCompareSide(compareBase, spec)
+	if err != nil {
+		color.Red("Error loading base: %v", err)
+		os.Exit(1)
+	}
+	cand, err := loadCompareSide(compareCandidate, spec)
+	if err != nil {
+		color.Red("Error loading candidate: %v", err)
+		os.Exit(1)
+	}
+
+	color.Cyan("⚖️  Comparing %s → %s on %d samples", base.name, cand.name, len(samples))
+	fmt.Println()
+
+	report, err := Compare(context.Background(), base, cand, samples)
+	if err != nil {
+		color.Red("Error comparing: %v", err)
+		os.Exit(1)
+	}
+	report.Datasets = args
+
+	printCompareReport(report)
+
+	if compareReport != "" {
+		if err := writeReport(report, compareReport); err != nil {
+			color.Red("Error saving report: %v", err)
+			os.Exit(1)
+		}
+		color.Green("✓ Report saved to: %s", compareReport)
+		fmt.Println()
+	}
+
+	if err := report.Gate(compareTolerance, compareMissing); err != nil {
+		color.Red("✗ %v", err)
+		os.Exit(1)
+	}
+	color.Green("✓ Attack recall within tolerance")
+}
+
+func printCompareReport(r *CompareReport) {
+	b, c := r.BaseMetrics, r.CandMetrics
+
+	color.Cyan("  Metrics")
+	fmt.Printf("  %-18s  %-10s  %-10s  %-8s\n", "Metric", "Base", "Candidate", "Delta")
+	fmt.Printf("  %s\n", strings.Repeat("-", 52))
+	fmt.Printf("  %-18s  %-10s  %-10s  %+.1f\n", "Recall", pct(b.Recall), pct(c.Recall), c.Recall-b.Recall)
+	fmt.Printf("  %-18s  %-10s  %-10s  %+.1f\n", "Precision", pct(b.Precision), pct(c.Precision), c.Precision-b.Precision)
+	fmt.Printf("  %-18s  %-10s  %-10s  %+.1f\n", "F1", pct(b.F1), pct(c.F1), c.F1-b.F1)
+	fmt.Printf("  %-18s  %-10s  %-10s  %+.1f\n", "FPR", pct(b.FPR), pct(c.FPR), c.FPR-b.FPR)
+	fmt.Printf("  %-18s  %-10d  %-10d  %+d\n", "False Positives", b.FP, c.FP, c.FP-b.FP)
+	fmt.Printf("  %-18s  %-10d  %-10d  %+d\n", "False Negatives", b.FN, c.FN, c.FN-b.FN)
+	if r.Missing > 0 {
+		color.Yellow("  %d samples skipped (not found in a result file)", r.Missing)
+	}
+	fmt.Println()
+
+	color.Cyan("  Per category")
+	fmt.Printf("  %-24s  %-8s  %-8s  %-8s  %-8s\n", "Category", "Base", "Cand", "Δ score", "Changed")
+	fmt.Printf("  %s\n", strings.Repeat("-", 64))
+	for _, cat := range sortedKeys(r.Categories) {
+		d := r.Categories[cat]
+		name := cat
+		if name == "" {
+			name = "(none)"
+		}
+		fmt.Printf("  %-24s  %8.2f  %8.2f  %+8.2f  %8d\n", name, d.BaseMeanScore, d.CandMeanScore, d.ScoreDelta, d.ChangedVerdict)
+	}
+	fmt.Println()
+
+	if len(r.Changes) == 0 {
+		color.Green("  No verdict changes")
+		fmt.Println()
+		return
+	}
+
+	color.Cyan("  Changed verdicts (%d)", len(r.Changes))
+	for _, ch := range r.Changes {
+		marker := color.GreenString("fixed    ")
+		if ch.Regression {
+			marker = color.RedString("regressed")
+		}
+		fmt.Printf("  %s [%s] %-6s cat=%-20s %.2f → %.2f  %q\n",
+			marker, ch.ID, ch.Label, ch.Category, ch.BaseScore, ch.CandScore, truncate(ch.Input, 60))
+	}
+	fmt.Println()
+}
+
+func pct(f float64) string {
+	return fmt.Sprintf("%.1f%%", f)
+}
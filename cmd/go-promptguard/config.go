@@ -2,10 +2,14 @@ package main
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/mdombrov-33/go-promptguard/detector"
+	"github.com/spf13/cobra"
 )
 
 type SavedConfig struct {
@@ -39,7 +43,82 @@ func getConfigPath() (string, error) {
 }
 
 func saveConfig(m *model) error {
-	cfg := SavedConfig{
+	cfg := configFromModel(m)
+
+	path, err := getConfigPath()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0644)
+}
+
+func loadConfig() (*SavedConfig, error) {
+	path, err := getConfigPath()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := loadConfigFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return cfg, err
+}
+
+// loadConfigFile reads a config in the same format the TUI saves,
+// so settings tuned interactively can be reused by eval and compare.
+func loadConfigFile(path string) (*SavedConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := defaultSavedConfig()
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+
+	return &cfg, nil
+}
+
+func defaultSavedConfig() SavedConfig {
+	return SavedConfig{
+		Threshold:          0.7,
+		EnableRoleInj:      true,
+		EnablePromptLeak:   true,
+		EnableInstOverride: true,
+		EnableObfuscation:  true,
+		EnableNorm:         true,
+		NormMode:           detector.ModeBalanced,
+		EnableDelim:        true,
+		DelimMode:          detector.ModeBalanced,
+		EnableEntropy:      true,
+		EnablePerp:         true,
+		EnableToken:        true,
+		EnableLLM:          false,
+		LLMMode:            1,
+		LLMProvider:        "none",
+	}
+}
+
+// defaultDetectors reports whether cfg runs the detectors with the settings
+// 'train' extracts features with. A trained model scored with other settings
+// sees features it was not trained on.
+func (cfg SavedConfig) defaultDetectors() bool {
+	d := defaultSavedConfig()
+	d.Threshold = cfg.Threshold
+	d.EnableLLM, d.LLMMode, d.LLMProvider = cfg.EnableLLM, cfg.LLMMode, cfg.LLMProvider
+	return cfg == d
+}
+
+func configFromModel(m *model) SavedConfig {
+	return SavedConfig{
 		Threshold:          m.threshold,
 		EnableRoleInj:      m.enableRoleInj,
 		EnablePromptLeak:   m.enablePromptLeak,
@@ -56,38 +135,210 @@ func saveConfig(m *model) error {
 		LLMMode:            m.llmMode,
 		LLMProvider:        m.llmProvider,
 	}
+}
 
-	path, err := getConfigPath()
-	if err != nil {
-		return err
+// detectorOptions converts a saved config into detector options, with judge
+// (see SavedConfig.judge) as the LLM judge when not nil.
+func (cfg SavedConfig) detectorOptions(judge detector.LLMJudge) []detector.Option {
+	opts := []detector.Option{
+		detector.WithThreshold(cfg.Threshold),
+		detector.WithRoleInjection(cfg.EnableRoleInj),
+		detector.WithPromptLeak(cfg.EnablePromptLeak),
+		detector.WithInstructionOverride(cfg.EnableInstOverride),
+		detector.WithObfuscation(cfg.EnableObfuscation),
+		detector.WithNormalization(cfg.EnableNorm),
+		detector.WithNormalizationMode(cfg.NormMode),
+		detector.WithDelimiter(cfg.EnableDelim),
+		detector.WithDelimiterMode(cfg.DelimMode),
+		detector.WithEntropy(cfg.EnableEntropy),
+		detector.WithPerplexity(cfg.EnablePerp),
+		detector.WithTokenAnomaly(cfg.EnableToken),
 	}
 
-	data, err := json.MarshalIndent(cfg, "", "  ")
-	if err != nil {
-		return err
+	if judge != nil {
+		opts = append(opts, detector.WithLLM(judge, llmRunMode(cfg.LLMMode)))
 	}
 
-	return os.WriteFile(path, data, 0644)
+	return opts
 }
 
-func loadConfig() (*SavedConfig, error) {
-	path, err := getConfigPath()
-	if err != nil {
-		return nil, err
+// judge builds the configured LLM judge from environment variables (see newJudge).
+// Returns nil if the LLM is disabled or the provider is unknown.
+func (cfg SavedConfig) judge() detector.LLMJudge {
+	if !cfg.EnableLLM || cfg.LLMProvider == "" || cfg.LLMProvider == "none" {
+		return nil
 	}
+	return newJudge(cfg.LLMProvider)
+}
 
-	data, err := os.ReadFile(path)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, nil
+// newJudge builds an LLM judge for a provider name using the same
+// environment variables as the TUI. A comma-separated list ("openai,ollama")
+// builds a failover chain in that order. Returns nil for unknown providers.
+func newJudge(provider string) detector.LLMJudge {
+	if strings.Contains(provider, ",") {
+		var judges []detector.LLMJudge
+		for _, name := range strings.Split(provider, ",") {
+			judge := newJudge(strings.TrimSpace(name))
+			if judge == nil {
+				return nil
+			}
+			judges = append(judges, judge)
 		}
-		return nil, err
+		return detector.NewFailoverJudge(judges...)
 	}
 
-	var cfg SavedConfig
-	if err := json.Unmarshal(data, &cfg); err != nil {
-		return nil, err
+	switch provider {
+	case "openai":
+		model := os.Getenv("OPENAI_MODEL")
+		if model == "" {
+			model = "gpt-5"
+		}
+		return detector.NewOpenAIJudge(os.Getenv("OPENAI_API_KEY"), model,
+			detector.WithOutputFormat(detector.LLMStructured))
+	case "openrouter":
+		model := os.Getenv("OPENROUTER_MODEL")
+		if model == "" {
+			model = "anthropic/claude-sonnet-4.5"
+		}
+		return detector.NewOpenRouterJudge(os.Getenv("OPENROUTER_API_KEY"), model,
+			detector.WithOutputFormat(detector.LLMStructured))
+	case "anthropic":
+		model := os.Getenv("ANTHROPIC_MODEL")
+		if model == "" {
+			model = "claude-sonnet-4-5"
+		}
+		return detector.NewAnthropicJudge(os.Getenv("ANTHROPIC_API_KEY"), model,
+			detector.WithOutputFormat(detector.LLMStructured))
+	case "azure":
+		deployment := os.Getenv("AZURE_OPENAI_DEPLOYMENT")
+		if deployment == "" {
+			deployment = "gpt-4o"
+		}
+		return detector.NewAzureOpenAIJudge(os.Getenv("AZURE_OPENAI_ENDPOINT"), os.Getenv("AZURE_OPENAI_API_KEY"),
+			deployment, os.Getenv("AZURE_OPENAI_API_VERSION"),
+			detector.WithOutputFormat(detector.LLMStructured))
+	case "gemini":
+		model := os.Getenv("GEMINI_MODEL")
+		if model == "" {
+			model = "gemini-2.5-flash"
+		}
+		return detector.NewGeminiJudge(os.Getenv("GEMINI_API_KEY"), model,
+			detector.WithOutputFormat(detector.LLMStructured))
+	case "ollama":
+		ollamaHost := os.Getenv("OLLAMA_HOST")
+		if ollamaHost == "" {
+			ollamaHost = os.Getenv("OLLAMA_API_BASE")
+		}
+
+		ollamaModel := os.Getenv("OLLAMA_MODEL")
+		if ollamaModel == "" {
+			ollamaModel = "llama3.1:8b"
+		}
+
+		if ollamaHost != "" {
+			return detector.NewOllamaJudgeWithEndpoint(ollamaHost, ollamaModel,
+				detector.WithLLMTimeout(60*time.Second),
+				detector.WithOutputFormat(detector.LLMStructured))
+		}
+		return detector.NewOllamaJudge(ollamaModel,
+			detector.WithLLMTimeout(60*time.Second),
+			detector.WithOutputFormat(detector.LLMStructured))
 	}
+	return nil
+}
 
-	return &cfg, nil
+func llmRunMode(mode int) detector.LLMRunMode {
+	switch mode {
+	case 0:
+		return detector.LLMAlways
+	case 1:
+		return detector.LLMConditional
+	case 2:
+		return detector.LLMFallback
+	case 3:
+		return detector.LLMVerify
+	default:
+		return detector.LLMConditional
+	}
+}
+
+// addGuardFlags registers the flags that describe a detector configuration.
+func addGuardFlags(cmd *cobra.Command, config *string, threshold *float64, model, calibration, llm, llmMode *string) {
+	cmd.Flags().StringVarP(config, "config", "c", "", "Config file in the format saved by the TUI")
+	cmd.Flags().Float64VarP(threshold, "threshold", "t", 0.7, "Risk threshold (0.0-1.0), overrides the config file")
+	cmd.Flags().StringVar(model, "model", "", "Score with a trained model (see 'train')")
+	cmd.Flags().StringVar(calibration, "calibration", "", "Apply a score calibration (see 'calibrate')")
+	cmd.Flags().StringVar(llm, "llm", "", "LLM judge provider: openai, openrouter, anthropic, azure, gemini or ollama (comma-separated for failover)")
+	cmd.Flags().StringVar(llmMode, "llm-mode", "conditional", "LLM run mode: always, conditional, fallback or verify")
+}
+
+// guardSpec describes how to build a detector from command-line flags.
+type guardSpec struct {
+	config       string
+	threshold    float64
+	thresholdSet bool
+	model        string
+	calibration  string
+	llm          string
+	llmMode      string
+}
+
+// buildGuard creates a MultiDetector from a guard spec and returns the effective config.
+func buildGuard(spec guardSpec) (*detector.MultiDetector, SavedConfig, error) {
+	cfg := defaultSavedConfig()
+	if spec.config != "" {
+		loaded, err := loadConfigFile(spec.config)
+		if err != nil {
+			return nil, cfg, err
+		}
+		cfg = *loaded
+	}
+
+	if spec.thresholdSet || spec.config == "" {
+		cfg.Threshold = spec.threshold
+	}
+
+	if spec.llm != "" {
+		cfg.EnableLLM = true
+		cfg.LLMProvider = spec.llm
+		switch spec.llmMode {
+		case "always":
+			cfg.LLMMode = 0
+		case "conditional":
+			cfg.LLMMode = 1
+		case "fallback":
+			cfg.LLMMode = 2
+		case "verify":
+			cfg.LLMMode = 3
+		default:
+			return nil, cfg, fmt.Errorf("unknown LLM mode: %s", spec.llmMode)
+		}
+	}
+
+	judge := cfg.judge()
+	if spec.llm != "" && judge == nil {
+		return nil, cfg, fmt.Errorf("unknown LLM provider: %s", spec.llm)
+	}
+	opts := cfg.detectorOptions(judge)
+
+	if spec.model != "" {
+		if !cfg.defaultDetectors() {
+			return nil, cfg, fmt.Errorf("--model needs the default detector settings 'train' uses, but the config changes them")
+		}
+		model, err := detector.LoadModel(spec.model)
+		if err != nil {
+			return nil, cfg, err
+		}
+		opts = append(opts, detector.WithClassifier(model))
+	}
+
+	if spec.calibration != "" {
+		cal, err := detector.LoadCalibration(spec.calibration)
+		if err != nil {
+			return nil, cfg, err
+		}
+		opts = append(opts, detector.WithCalibrator(cal))
+	}
+
+	return detector.New(opts...), cfg, nil
 }
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"github.com/fatih/color"
+	"github.com/mdombrov-33/go-promptguard/dataset"
+	"github.com/spf13/cobra"
+)
+
+// datasetFlags holds the dataset loading flags shared by eval, calibrate, train, compare and fuzz.
+type datasetFlags struct {
+	fields map[string]string
+	labels map[string]string
+	dedup  bool
+}
+
+func addDatasetFlags(cmd *cobra.Command, f *datasetFlags) {
+	cmd.Flags().StringToStringVar(&f.fields, "field", nil, "Map sample fields to dataset columns, e.g. input=prompt,label=type")
+	cmd.Flags().StringToStringVar(&f.labels, "label-map", nil, "Extra label values, e.g. jailbreak_v2=attack,2=safe")
+	cmd.Flags().BoolVar(&f.dedup, "dedup", false, "Drop samples with duplicate inputs")
+}
+
+// load reads JSON, JSONL or CSV datasets with the configured mapping.
+// Extra options (e.g. a default label) are applied after the flags.
+func (f datasetFlags) load(paths []string, opts ...dataset.Option) ([]dataset.Sample, error) {
+	mapping, err := dataset.ParseMapping(f.fields)
+	if err != nil {
+		return nil, err
+	}
+	opts = append([]dataset.Option{dataset.WithMapping(mapping), dataset.WithLabels(f.labels)}, opts...)
+
+	samples, err := dataset.LoadAll(paths, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.dedup {
+		var stats dataset.DedupStats
+		samples, stats = dataset.Dedup(samples)
+		if stats.Duplicates > 0 {
+			color.Yellow("Removed %d duplicate samples (%d with conflicting labels)", stats.Duplicates, stats.Conflicts)
+		}
+	}
+	return samples, nil
+}
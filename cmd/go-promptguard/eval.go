@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/mdombrov-33/go-promptguard/dataset"
+	"github.com/mdombrov-33/go-promptguard/detector"
+)
+
+// Metrics is the JSON form of a confusion matrix with its derived values (percentages).
+type Metrics struct {
+	TP        int     `json:"tp"`
+	FP        int     `json:"fp"`
+	TN        int     `json:"tn"`
+	FN        int     `json:"fn"`
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+	F1        float64 `json:"f1"`
+	Accuracy  float64 `json:"accuracy"`
+	FPR       float64 `json:"fpr"`
+}
+
+func metricsOf(c detector.ConfusionMatrix) Metrics {
+	return Metrics{
+		TP:        c.TP,
+		FP:        c.FP,
+		TN:        c.TN,
+		FN:        c.FN,
+		Precision: c.Precision(),
+		Recall:    c.Recall(),
+		F1:        c.F1(),
+		Accuracy:  c.Accuracy(),
+		FPR:       c.FPR(),
+	}
+}
+
+// PatternStats counts how often a pattern type fired on attacks and on benign inputs.
+type PatternStats struct {
+	Attacks   int     `json:"attacks"`
+	Benign    int     `json:"benign"`
+	Precision float64 `json:"precision"` // % of the inputs it fired on that were attacks
+	Coverage  float64 `json:"coverage"`  // % of all attacks it fired on
+}
+
+// EvalResult pairs a sample with the detector result so we never call Detect twice.
+type EvalResult struct {
+	Sample dataset.Sample
+	Result detector.Result
+}
+
+// Misclassification is a false positive or false negative in the report.
+type Misclassification struct {
+	ID        string   `json:"id"`
+	Category  string   `json:"category"`
+	Input     string   `json:"input"`
+	RiskScore float64  `json:"risk_score"`
+	Patterns  []string `json:"patterns"`
+	Notes     string   `json:"notes,omitempty"`
+}
+
+// EvalReport is the machine-readable result of an evaluation run.
+type EvalReport struct {
+	GeneratedAt    time.Time               `json:"generated_at"`
+	Datasets       []string                `json:"datasets"`
+	Config         SavedConfig             `json:"config"`
+	Model          string                  `json:"model,omitempty"`
+	Calibration    string                  `json:"calibration,omitempty"`
+	Total          int                     `json:"total"`
+	Attacks        int                     `json:"attacks"`
+	Benign         int                     `json:"benign"`
+	Skipped        int                     `json:"skipped"` // samples without an attack/safe label
+	Overall        Metrics                 `json:"overall"`
+	Categories     map[string]Metrics      `json:"categories"`
+	Patterns       map[string]PatternStats `json:"patterns"`
+	FalsePositives []Misclassification     `json:"false_positives"`
+	FalseNegatives []Misclassification     `json:"false_negatives"`
+	Duration       time.Duration           `json:"duration_ns"`
+
+	Results []EvalResult `json:"-"`
+}
+
+// Evaluate runs every labeled sample through the guard once and builds the report.
+func Evaluate(ctx context.Context, guard *detector.MultiDetector, samples []dataset.Sample) *EvalReport {
+	results := make([]EvalResult, 0, len(samples))
+	skipped := 0
+	startTime := time.Now()
+
+	for _, s := range samples {
+		if !s.Labeled() {
+			skipped++
+			continue
+		}
+		results = append(results, EvalResult{Sample: s, Result: guard.Detect(ctx, s.Input)})
+	}
+
+	report := summarize(results)
+	report.Skipped = skipped
+	report.Duration = time.Since(startTime)
+	return report
+}
+
+// summarize computes the report metrics from already scored samples.
+func summarize(results []EvalResult) *EvalReport {
+	report := &EvalReport{
+		GeneratedAt:    time.Now().UTC(),
+		Categories:     make(map[string]Metrics),
+		Patterns:       make(map[string]PatternStats),
+		FalsePositives: []Misclassification{},
+		FalseNegatives: []Misclassification{},
+		Results:        results,
+	}
+
+	var overall detector.ConfusionMatrix
+	perCategory := make(map[string]detector.ConfusionMatrix)
+
+	for _, er := range results {
+		s, result := er.Sample, er.Result
+
+		predicted := !result.Safe
+		actual := s.IsAttack()
+		if actual {
+			report.Attacks++
+		} else {
+			report.Benign++
+		}
+
+		overall.Add(predicted, actual)
+		c := perCategory[s.Category]
+		c.Add(predicted, actual)
+		perCategory[s.Category] = c
+
+		for _, patternType := range uniquePatternTypes(result) {
+			stats := report.Patterns[patternType]
+			if actual {
+				stats.Attacks++
+			} else {
+				stats.Benign++
+			}
+			report.Patterns[patternType] = stats
+		}
+
+		if predicted != actual {
+			m := Misclassification{
+				ID:        s.ID,
+				Category:  s.Category,
+				Input:     s.Input,
+				RiskScore: result.RiskScore,
+				Patterns:  uniquePatternTypes(result),
+				Notes:     s.Notes,
+			}
+			if predicted {
+				report.FalsePositives = append(report.FalsePositives, m)
+			} else {
+				report.FalseNegatives = append(report.FalseNegatives, m)
+			}
+		}
+	}
+
+	report.Total = report.Attacks + report.Benign
+	report.Overall = metricsOf(overall)
+	for cat, c := range perCategory {
+		report.Categories[cat] = metricsOf(c)
+	}
+	for patternType, stats := range report.Patterns {
+		stats.Precision = float64(stats.Attacks) / float64(stats.Attacks+stats.Benign) * 100
+		if report.Attacks > 0 {
+			stats.Coverage = float64(stats.Attacks) / float64(report.Attacks) * 100
+		}
+		report.Patterns[patternType] = stats
+	}
+
+	return report
+}
+
+// uniquePatternTypes returns the sorted, deduplicated pattern types of a result.
+// Decoded input variants can report the same pattern more than once.
+func uniquePatternTypes(result detector.Result) []string {
+	seen := make(map[string]bool)
+	types := []string{}
+	for _, p := range result.DetectedPatterns {
+		if !seen[p.Type] {
+			seen[p.Type] = true
+			types = append(types, p.Type)
+		}
+	}
+	sort.Strings(types)
+	return types
+}
+
+// sortedKeys returns map keys in alphabetical order for stable output.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func writeReport(report any, outputPath string) error {
+	dir := filepath.Dir(outputPath)
+	if dir != "." && dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
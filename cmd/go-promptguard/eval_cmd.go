@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var (
+	evalConfig      string
+	evalThreshold   float64
+	evalModel       string
+	evalCalibration string
+	evalLLM         string
+	evalLLMMode     string
+	evalReport      string
+	evalData        datasetFlags
+)
+
+var evalCmd = &cobra.Command{
+	Use:   "eval [dataset...]",
+	Short: "Measure detection accuracy on labeled datasets",
+	Long: `Run a configuration against labeled datasets and report precision, recall
+and F1 overall, per category and per pattern, plus every false positive and
+false negative.
+
+Datasets can be JSON (benchmarks/testdata format), JSONL (one sample per
+line) or CSV (header with id, input, label, category, notes). Use --field
+to map differently named columns and --label-map for unusual label values.
+
+Examples:
+  # Default configuration
+  go-promptguard eval attacks.json benign.json
+
+  # Configuration saved from the TUI, with a report for tracking over time
+  go-promptguard eval data.jsonl --config ~/.config/go-promptguard/config.json --report report.json
+
+  # With an LLM judge (keys read from environment / .env)
+  go-promptguard eval data.csv --llm openai --llm-mode fallback
+
+  # With a trained model and calibration
+  go-promptguard eval data.json --model model.json --calibration calibration.json
+
+  # Public dataset with its own column names and labels
+  go-promptguard eval injections.csv --field input=text,label=type --label-map jailbreak_v2=attack --dedup`,
+	Run: runEval,
+}
+
+func init() {
+	rootCmd.AddCommand(evalCmd)
+
+	addGuardFlags(evalCmd, &evalConfig, &evalThreshold, &evalModel, &evalCalibration, &evalLLM, &evalLLMMode)
+	addDatasetFlags(evalCmd, &evalData)
+	evalCmd.Flags().StringVarP(&evalReport, "report", "o", "", "Write a JSON report to this file")
+}
+
+func runEval(cmd *cobra.Command, args []string) {
+	if len(args) == 0 {
+		color.Red("Error: no dataset provided")
+		fmt.Println("\nUsage: go-promptguard eval [dataset...]")
+		os.Exit(1)
+	}
+
+	samples, err := evalData.load(args)
+	if err != nil {
+		color.Red("Error loading dataset: %v", err)
+		os.Exit(1)
+	}
+
+	guard, cfg, err := buildGuard(guardSpec{
+		config:       evalConfig,
+		threshold:    evalThreshold,
+		thresholdSet: cmd.Flags().Changed("threshold"),
+		model:        evalModel,
+		calibration:  evalCalibration,
+		llm:          evalLLM,
+		llmMode:      evalLLMMode,
+	})
+	if err != nil {
+		color.Red("Error building detector: %v", err)
+		os.Exit(1)
+	}
+
+	color.Cyan("🧪 Evaluating %d samples (threshold=%.2f)", len(samples), cfg.Threshold)
+	fmt.Println()
+
+	report := Evaluate(context.Background(), guard, samples)
+	report.Datasets = args
+	report.Config = cfg
+	report.Model = evalModel
+	report.Calibration = evalCalibration
+
+	printEvalReport(report)
+
+	if evalReport != "" {
+		if err := writeReport(report, evalReport); err != nil {
+			color.Red("Error saving report: %v", err)
+			os.Exit(1)
+		}
+		color.Green("✓ Report saved to: %s", evalReport)
+		fmt.Println()
+	}
+}
+
+func printEvalReport(r *EvalReport) {
+	o := r.Overall
+
+	color.Cyan("  Overall")
+	fmt.Printf("  Samples:          %d (%d attacks, %d benign", r.Total, r.Attacks, r.Benign)
+	if r.Skipped > 0 {
+		fmt.Printf(", %d skipped without label", r.Skipped)
+	}
+	fmt.Println(")")
+	fmt.Printf("  Accuracy:         %.1f%%\n", o.Accuracy)
+	fmt.Printf("  Precision:        %.1f%%\n", o.Precision)
+	fmt.Printf("  Recall:           %.1f%%\n", o.Recall)
+	fmt.Printf("  F1 Score:         %.1f%%\n", o.F1)
+	fmt.Printf("  False Positives:  %d (%.1f%% of benign)\n", o.FP, o.FPR)
+	fmt.Printf("  False Negatives:  %d\n", o.FN)
+	fmt.Printf("  Duration:         %s\n", r.Duration.Round(time.Millisecond))
+	fmt.Println()
+
+	color.Cyan("  Per category")
+	fmt.Printf("  %-24s  %-7s  %-7s  %-7s  %-4s  %-4s  %-4s  %-4s\n", "Category", "Recall", "Prec", "FPR", "TP", "FP", "TN", "FN")
+	fmt.Printf("  %s\n", strings.Repeat("-", 74))
+	for _, cat := range sortedKeys(r.Categories) {
+		m := r.Categories[cat]
+		name := cat
+		if name == "" {
+			name = "(none)"
+		}
+		fmt.Printf("  %-24s  %6.1f%%  %6.1f%%  %6.1f%%  %4d  %4d  %4d  %4d\n",
+			name, m.Recall, m.Precision, m.FPR, m.TP, m.FP, m.TN, m.FN)
+	}
+	fmt.Println()
+
+	if len(r.Patterns) > 0 {
+		color.Cyan("  Per pattern")
+		fmt.Printf("  %-40s  %-7s  %-7s  %-8s  %-9s\n", "Pattern", "Attacks", "Benign", "Prec", "Coverage")
+		fmt.Printf("  %s\n", strings.Repeat("-", 78))
+		for _, p := range sortedKeys(r.Patterns) {
+			s := r.Patterns[p]
+			fmt.Printf("  %-40s  %7d  %7d  %7.1f%%  %8.1f%%\n", p, s.Attacks, s.Benign, s.Precision, s.Coverage)
+		}
+		fmt.Println()
+	}
+
+	if len(r.FalsePositives) > 0 {
+		color.Yellow("  False positives (benign inputs flagged)")
+		for _, m := range r.FalsePositives {
+			printMisclassification(m)
+		}
+		fmt.Println()
+	}
+
+	if len(r.FalseNegatives) > 0 {
+		color.Red("  False negatives (attacks missed)")
+		for _, m := range r.FalseNegatives {
+			printMisclassification(m)
+		}
+		fmt.Println()
+	}
+}
+
+func printMisclassification(m Misclassification) {
+	fmt.Printf("  [%s] cat=%-22s score=%.2f  %q\n", m.ID, m.Category, m.RiskScore, truncate(m.Input, 70))
+	if len(m.Patterns) > 0 {
+		color.New(color.Faint).Printf("    └─ patterns: %s\n", strings.Join(m.Patterns, ", "))
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/mdombrov-33/go-promptguard/dataset"
+	"github.com/spf13/cobra"
+)
+
+var (
+	fuzzConfig      string
+	fuzzThreshold   float64
+	fuzzModel       string
+	fuzzCalibration string
+	fuzzLLM         string
+	fuzzLLMMode     string
+	fuzzOperators   []string
+	fuzzSeed        int64
+	fuzzOutput      string
+	fuzzData        datasetFlags
+)
+
+var fuzzCmd = &cobra.Command{
+	Use:   "fuzz [seed-dataset...]",
+	Short: "Mutate known attacks to find detection bypasses",
+	Long: `Apply evasion mutations to seed attacks and report the smallest mutated
+inputs the detector classifies as safe, grouped by mutation operator.
+
+Seeds are the attack samples of JSON, JSONL or CSV datasets (benign samples
+are ignored). Seeds the detector already misses are skipped.
+
+Operators: homoglyph, spacing, separators, casing, leetspeak, synonym,
+encoding, splitting, padding.
+
+Examples:
+  # Fuzz the bundled attacks with every operator
+  go-promptguard fuzz benchmarks/testdata/attacks.json
+
+  # Only some operators, save bypasses as a dataset for eval/train
+  go-promptguard fuzz attacks.json --operators homoglyph,synonym --output bypasses.json
+
+  # Fuzz a tuned configuration
+  go-promptguard fuzz attacks.json --config tuned.json --threshold 0.6`,
+	Run: runFuzz,
+}
+
+func init() {
+	rootCmd.AddCommand(fuzzCmd)
+
+	addGuardFlags(fuzzCmd, &fuzzConfig, &fuzzThreshold, &fuzzModel, &fuzzCalibration, &fuzzLLM, &fuzzLLMMode)
+	fuzzCmd.Flags().StringSliceVar(&fuzzOperators, "operators", nil, "Mutation operators to run (default all)")
+	fuzzCmd.Flags().Int64Var(&fuzzSeed, "seed", 1, "Random seed for mutations")
+	fuzzCmd.Flags().StringVarP(&fuzzOutput, "output", "o", "", "Save bypasses as a labeled dataset (JSON, JSONL or CSV)")
+	addDatasetFlags(fuzzCmd, &fuzzData)
+}
+
+func runFuzz(cmd *cobra.Command, args []string) {
+	if len(args) == 0 {
+		color.Red("Error: no seed dataset provided")
+		fmt.Println("\nUsage: go-promptguard fuzz [seed-dataset...]")
+		os.Exit(1)
+	}
+
+	samples, err := fuzzData.load(args)
+	if err != nil {
+		color.Red("Error loading seeds: %v", err)
+		os.Exit(1)
+	}
+	var seeds []dataset.Sample
+	for _, s := range samples {
+		if s.IsAttack() {
+			seeds = append(seeds, s)
+		}
+	}
+
+	operators, err := selectMutators(fuzzOperators)
+	if err != nil {
+		color.Red("Error: %v", err)
+		os.Exit(1)
+	}
+
+	guard, _, err := buildGuard(guardSpec{
+		config:       fuzzConfig,
+		threshold:    fuzzThreshold,
+		thresholdSet: cmd.Flags().Changed("threshold"),
+		model:        fuzzModel,
+		calibration:  fuzzCalibration,
+		llm:          fuzzLLM,
+		llmMode:      fuzzLLMMode,
+	})
+	if err != nil {
+		color.Red("Error building detector: %v", err)
+		os.Exit(1)
+	}
+
+	color.Cyan("🐛 Fuzzing %d seed attacks with %d operators", len(seeds), len(operators))
+	fmt.Println()
+
+	summary := Fuzz(context.Background(), guard, seeds, operators, fuzzSeed)
+
+	fmt.Printf("  Seeds detected:   %d\n", summary.Seeds)
+	if summary.Skipped > 0 {
+		fmt.Printf("  Already missed:   %d (skipped)\n", summary.Skipped)
+	}
+	fmt.Println()
+
+	fmt.Printf("  %-12s  %-8s  %-8s  %-8s\n", "Operator", "Tried", "Bypass", "Rate")
+	fmt.Printf("  %s\n", strings.Repeat("-", 44))
+	total := 0
+	for _, op := range operators {
+		tried, bypasses := summary.Tried[op.name], len(summary.Bypasses[op.name])
+		total += bypasses
+		rate := 0.0
+		if tried > 0 {
+			rate = float64(bypasses) / float64(tried) * 100
+		}
+		fmt.Printf("  %-12s  %8d  %8d  %7.1f%%\n", op.name, tried, bypasses, rate)
+	}
+	fmt.Println()
+
+	for _, op := range operators {
+		bypasses := summary.Bypasses[op.name]
+		if len(bypasses) == 0 {
+			continue
+		}
+		color.Yellow("  %s (%d)", op.name, len(bypasses))
+		for _, b := range bypasses {
+			fmt.Printf("  [%s] score=%.2f  %q\n", b.SeedID, b.RiskScore, truncate(b.Mutated, 80))
+		}
+		fmt.Println()
+	}
+
+	if total == 0 {
+		color.Green("✓ No bypasses found")
+		fmt.Println()
+	}
+
+	if fuzzOutput != "" {
+		if err := exportBypasses(summary, operators, fuzzOutput); err != nil {
+			color.Red("Error saving bypasses: %v", err)
+			os.Exit(1)
+		}
+		color.Green("✓ %d bypasses saved to: %s", total, fuzzOutput)
+		fmt.Println()
+	}
+}
+
+func selectMutators(names []string) ([]mutator, error) {
+	if len(names) == 0 {
+		return mutators, nil
+	}
+
+	var selected []mutator
+	for _, name := range names {
+		found := false
+		for _, m := range mutators {
+			if m.name == strings.TrimSpace(name) {
+				selected = append(selected, m)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown operator: %s", name)
+		}
+	}
+	return selected, nil
+}
+
+// exportBypasses writes bypasses in the benchmarks/testdata format so they can be
+// fed straight back into eval, train or a regression test.
+func exportBypasses(summary *FuzzSummary, operators []mutator, outputPath string) error {
+	var samples []dataset.Sample
+	for _, op := range operators {
+		for i, b := range summary.Bypasses[op.name] {
+			samples = append(samples, dataset.Sample{
+				ID:       fmt.Sprintf("fuzz_%s_%s_%d", op.name, b.SeedID, i+1),
+				Input:    b.Mutated,
+				Label:    dataset.LabelAttack,
+				Category: b.Category,
+				Notes:    fmt.Sprintf("fuzz: %s mutation of %s", op.name, b.SeedID),
+			})
+		}
+	}
+
+	return dataset.Save(outputPath, samples)
+}
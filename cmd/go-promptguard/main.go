@@ -25,6 +25,11 @@ MODES:
   Quick Check      - go-promptguard check "input text"
   Batch Process    - go-promptguard batch inputs.txt
   HTTP Server      - go-promptguard server --port 8080
+  Evaluate         - go-promptguard eval attacks.json benign.json
+  Compare          - go-promptguard compare data.json --candidate config.json
+  Train Model      - go-promptguard train attacks.json benign.json
+  Calibrate        - go-promptguard calibrate --attacks a.json --benign b.json
+  Fuzz             - go-promptguard fuzz attacks.json
 
 Run 'go-promptguard [command] --help' for more information.`,
 	Run: func(cmd *cobra.Command, args []string) {
@@ -3,7 +3,6 @@ package main
 import (
 	"context"
 	"os"
-	"time"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
@@ -83,6 +82,24 @@ func initialModel() model {
 			defaultProvider = "openrouter"
 		}
 	}
+	if os.Getenv("ANTHROPIC_API_KEY") != "" {
+		availableProviders = append(availableProviders, "anthropic")
+		if defaultProvider == "none" {
+			defaultProvider = "anthropic"
+		}
+	}
+	if os.Getenv("AZURE_OPENAI_API_KEY") != "" && os.Getenv("AZURE_OPENAI_ENDPOINT") != "" {
+		availableProviders = append(availableProviders, "azure")
+		if defaultProvider == "none" {
+			defaultProvider = "azure"
+		}
+	}
+	if os.Getenv("GEMINI_API_KEY") != "" {
+		availableProviders = append(availableProviders, "gemini")
+		if defaultProvider == "none" {
+			defaultProvider = "gemini"
+		}
+	}
 	availableProviders = append(availableProviders, "ollama")
 	if defaultProvider == "none" {
 		defaultProvider = "ollama"
@@ -141,79 +158,12 @@ func initialModel() model {
 }
 
 func (m *model) updateGuard() {
-	opts := []detector.Option{
-		detector.WithThreshold(m.threshold),
-		detector.WithRoleInjection(m.enableRoleInj),
-		detector.WithPromptLeak(m.enablePromptLeak),
-		detector.WithInstructionOverride(m.enableInstOverride),
-		detector.WithObfuscation(m.enableObfuscation),
-		detector.WithNormalization(m.enableNorm),
-		detector.WithNormalizationMode(m.normMode),
-		detector.WithDelimiter(m.enableDelim),
-		detector.WithDelimiterMode(m.delimMode),
-		detector.WithEntropy(m.enableEntropy),
-		detector.WithPerplexity(m.enablePerp),
-		detector.WithTokenAnomaly(m.enableToken),
-	}
-
-	if m.enableLLM && m.llmProvider != "" && m.llmProvider != "none" {
-		var judge detector.LLMJudge
-		switch m.llmProvider {
-		case "openai":
-			model := os.Getenv("OPENAI_MODEL")
-			if model == "" {
-				model = "gpt-5"
-			}
-			judge = detector.NewOpenAIJudge(os.Getenv("OPENAI_API_KEY"), model,
-				detector.WithOutputFormat(detector.LLMStructured))
-		case "openrouter":
-			model := os.Getenv("OPENROUTER_MODEL")
-			if model == "" {
-				model = "anthropic/claude-sonnet-4.5"
-			}
-			judge = detector.NewOpenRouterJudge(os.Getenv("OPENROUTER_API_KEY"), model,
-				detector.WithOutputFormat(detector.LLMStructured))
-		case "ollama":
-			ollamaHost := os.Getenv("OLLAMA_HOST")
-			if ollamaHost == "" {
-				ollamaHost = os.Getenv("OLLAMA_API_BASE")
-			}
-
-			ollamaModel := os.Getenv("OLLAMA_MODEL")
-			if ollamaModel == "" {
-				ollamaModel = "llama3.1:8b"
-			}
-
-			if ollamaHost != "" {
-				judge = detector.NewOllamaJudgeWithEndpoint(ollamaHost, ollamaModel,
-					detector.WithLLMTimeout(60*time.Second),
-					detector.WithOutputFormat(detector.LLMStructured))
-			} else {
-				judge = detector.NewOllamaJudge(ollamaModel,
-					detector.WithLLMTimeout(60*time.Second),
-					detector.WithOutputFormat(detector.LLMStructured))
-			}
-		}
-
-		if judge != nil {
-			go judge.Warmup(context.Background())
-
-			var mode detector.LLMRunMode
-			switch m.llmMode {
-			case 0:
-				mode = detector.LLMAlways
-			case 1:
-				mode = detector.LLMConditional
-			case 2:
-				mode = detector.LLMFallback
-			default:
-				mode = detector.LLMConditional
-			}
-			opts = append(opts, detector.WithLLM(judge, mode))
-		}
+	cfg := configFromModel(m)
+	judge := cfg.judge()
+	if judge != nil {
+		go judge.Warmup(context.Background())
 	}
-
-	m.guard = detector.New(opts...)
+	m.guard = detector.New(cfg.detectorOptions(judge)...)
 }
 
 func (m model) Init() tea.Cmd {
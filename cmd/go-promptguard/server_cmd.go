@@ -1,7 +1,6 @@
 package main
 
 import (
-	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -16,6 +15,7 @@ import (
 var (
 	port            int
 	serverThreshold float64
+	serverFailClose bool
 )
 
 var serverCmd = &cobra.Command{
@@ -34,6 +34,9 @@ Examples:
   # Custom threshold
   go-promptguard server --threshold 0.8
 
+  # Treat requests whose check did not finish (client gone, LLM error) as unsafe
+  go-promptguard server --fail-closed
+
 API Endpoints:
   POST /detect - Check input for prompt injection
     Request body: {"input": "text to check"}
@@ -49,6 +52,7 @@ func init() {
 
 	serverCmd.Flags().IntVarP(&port, "port", "p", 8080, "Port to listen on")
 	serverCmd.Flags().Float64VarP(&serverThreshold, "threshold", "t", 0.7, "Risk threshold (0.0-1.0)")
+	serverCmd.Flags().BoolVar(&serverFailClose, "fail-closed", false, "Mark inputs unsafe when detection does not finish")
 }
 
 type detectRequest struct {
@@ -60,8 +64,13 @@ type errorResponse struct {
 }
 
 func runServer(cmd *cobra.Command, args []string) {
+	policy := detector.FailOpen
+	if serverFailClose {
+		policy = detector.FailClosed
+	}
 	guard := detector.New(
 		detector.WithThreshold(serverThreshold),
+		detector.WithFailurePolicy(policy),
 	)
 
 	http.HandleFunc("/detect", makeDetectHandler(guard))
@@ -122,8 +131,8 @@ func makeDetectHandler(guard *detector.MultiDetector) http.HandlerFunc {
 			return
 		}
 
-		ctx := context.Background()
-		result := guard.Detect(ctx, req.Input)
+		// The request context stops detection when the client disconnects
+		result := guard.Detect(r.Context(), req.Input)
 
 		status := "SAFE"
 		if !result.Safe {
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/mdombrov-33/go-promptguard/dataset"
+	"github.com/mdombrov-33/go-promptguard/detector"
+	"github.com/spf13/cobra"
+)
+
+var (
+	trainType         string
+	trainOutput       string
+	trainFolds        int
+	trainIterations   int
+	trainLearningRate float64
+	trainL2           float64
+	trainTestSplit    float64
+	trainSplitSeed    int64
+	trainData         datasetFlags
+)
+
+var trainCmd = &cobra.Command{
+	Use:   "train [dataset...]",
+	Short: "Train a classifier over detector features from labeled data",
+	Long: `Train a lightweight classifier on labeled JSON, JSONL or CSV datasets (see
+'eval --help' for column mapping) and save it for use with --model or detector.LoadModel.
+
+Every detector's output (pattern hits, entropy, rare-bigram ratio,
+special-char ratio, ...) becomes a feature vector. The trained model
+replaces the hand-tuned weighted score as the final scorer.
+
+Examples:
+  # Logistic regression (default)
+  go-promptguard train benchmarks/testdata/attacks.json benchmarks/testdata/benign.json
+
+  # Gradient-boosted stumps with 10-fold cross-validation
+  go-promptguard train attacks.json benign.json --type stumps --folds 10
+
+  # Deduplicate and hold out 20% for a final test
+  go-promptguard train data.jsonl --dedup --test-split 0.2
+
+  # Use the model
+  go-promptguard check "input" --model model.json`,
+	Run: runTrain,
+}
+
+func init() {
+	rootCmd.AddCommand(trainCmd)
+
+	trainCmd.Flags().StringVar(&trainType, "type", detector.ModelLogistic, "Model type: logistic or stumps")
+	trainCmd.Flags().StringVarP(&trainOutput, "output", "o", "model.json", "Where to save the trained model")
+	trainCmd.Flags().IntVar(&trainFolds, "folds", 5, "Cross-validation folds (0 to skip)")
+	trainCmd.Flags().IntVar(&trainIterations, "iterations", 0, "Epochs (logistic) or boosting rounds (stumps), 0 for default")
+	trainCmd.Flags().Float64Var(&trainLearningRate, "learning-rate", 0, "Learning rate, 0 for default")
+	trainCmd.Flags().Float64Var(&trainL2, "l2", 0, "L2 regularization (logistic), 0 for default, negative to turn it off")
+	trainCmd.Flags().Float64Var(&trainTestSplit, "test-split", 0, "Hold out this fraction (0.0-1.0) for a stratified test set")
+	trainCmd.Flags().Int64Var(&trainSplitSeed, "split-seed", 1, "Random seed for --test-split")
+	addDatasetFlags(trainCmd, &trainData)
+}
+
+func runTrain(cmd *cobra.Command, args []string) {
+	if len(args) == 0 {
+		color.Red("Error: no dataset provided")
+		fmt.Println("\nUsage: go-promptguard train [dataset...]")
+		os.Exit(1)
+	}
+
+	loaded, err := trainData.load(args)
+	if err != nil {
+		color.Red("Error loading dataset: %v", err)
+		os.Exit(1)
+	}
+	var samples []dataset.Sample
+	for _, s := range loaded {
+		if s.Labeled() {
+			samples = append(samples, s)
+		}
+	}
+
+	// Features come from the pattern detectors only, so train with the full default set.
+	guard := detector.New()
+	ctx := context.Background()
+
+	samples, test := dataset.Split(samples, trainTestSplit, trainSplitSeed)
+	x, y := featurize(ctx, guard, samples)
+	attacks := 0
+	for _, s := range samples {
+		if s.IsAttack() {
+			attacks++
+		}
+	}
+
+	opts := detector.TrainOptions{
+		Type:         trainType,
+		Iterations:   trainIterations,
+		LearningRate: trainLearningRate,
+		L2:           trainL2,
+	}
+
+	color.Cyan("🧠 Training %s model on %d samples (%d attacks, %d benign)", trainType, len(samples), attacks, len(samples)-attacks)
+	fmt.Println()
+
+	if trainFolds > 0 {
+		metrics, err := detector.CrossValidate(x, y, trainFolds, opts)
+		if err != nil {
+			color.Red("Error during cross-validation: %v", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("  Cross-validation (%d folds)\n", metrics.Folds)
+		fmt.Printf("  Accuracy:   %.1f%%\n", metrics.Accuracy())
+		fmt.Printf("  Precision:  %.1f%%\n", metrics.Precision())
+		fmt.Printf("  Recall:     %.1f%%\n", metrics.Recall())
+		fmt.Printf("  F1 Score:   %.1f%%\n", metrics.F1())
+		fmt.Printf("  Log loss:   %.3f\n", metrics.LogLoss)
+		fmt.Printf("  TP %d  FP %d  TN %d  FN %d\n", metrics.TP, metrics.FP, metrics.TN, metrics.FN)
+		fmt.Println()
+	}
+
+	startTime := time.Now()
+	model, err := detector.TrainModel(x, y, opts)
+	if err != nil {
+		color.Red("Error training model: %v", err)
+		os.Exit(1)
+	}
+
+	if len(test) > 0 {
+		var counts detector.ConfusionMatrix
+		testX, testY := featurize(ctx, guard, test)
+		for i := range testX {
+			counts.Add(model.Predict(testX[i]) >= 0.5, testY[i])
+		}
+
+		fmt.Printf("  Held-out test set (%d samples)\n", len(test))
+		fmt.Printf("  Accuracy:   %.1f%%\n", counts.Accuracy())
+		fmt.Printf("  Precision:  %.1f%%\n", counts.Precision())
+		fmt.Printf("  Recall:     %.1f%%\n", counts.Recall())
+		fmt.Printf("  F1 Score:   %.1f%%\n", counts.F1())
+		fmt.Printf("  TP %d  FP %d  TN %d  FN %d\n", counts.TP, counts.FP, counts.TN, counts.FN)
+		fmt.Println()
+	}
+
+	if err := model.Save(trainOutput); err != nil {
+		color.Red("Error saving model: %v", err)
+		os.Exit(1)
+	}
+
+	color.Green("✓ Model saved to: %s (%s)", trainOutput, time.Since(startTime).Round(time.Millisecond))
+	fmt.Println()
+}
+
+func featurize(ctx context.Context, guard *detector.MultiDetector, samples []dataset.Sample) ([][]float64, []bool) {
+	x := make([][]float64, 0, len(samples))
+	y := make([]bool, 0, len(samples))
+	for _, s := range samples {
+		x = append(x, guard.Features(ctx, s.Input))
+		y = append(y, s.IsAttack())
+	}
+	return x, y
+}
@@ -226,7 +226,7 @@ func (m *model) toggleSetting(choice int) bool {
 		m.enableLLM = !m.enableLLM
 		return true
 	case 13:
-		m.llmMode = (m.llmMode + 1) % 3
+		m.llmMode = (m.llmMode + 1) % 4
 		return true
 	case 14:
 		if len(m.availableProviders) > 0 {
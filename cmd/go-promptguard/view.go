@@ -110,7 +110,7 @@ func (m model) viewCheck() string {
 		s.WriteString("\n\n")
 		s.WriteString(lipgloss.Place(m.width, 0, lipgloss.Center, lipgloss.Top, loading))
 	} else if m.enableLLM && m.llmProvider != "none" {
-		modeNames := []string{"Always", "Conditional", "Fallback"}
+		modeNames := []string{"Always", "Conditional", "Fallback", "Verify"}
 		llmStatus := fmt.Sprintf("LLM: %s (%s mode)", capitalizeProviderName(m.llmProvider), modeNames[m.llmMode])
 		llmInfo := lipgloss.NewStyle().Foreground(secondaryColor).Render(llmStatus)
 		s.WriteString("\n")
@@ -200,6 +200,13 @@ func (m model) viewResults() string {
 		}
 	}
 
+	if len(m.result.Overridden) > 0 {
+		metricsContent.WriteString("\nCleared by LLM:\n")
+		for _, pattern := range m.result.Overridden {
+			metricsContent.WriteString(lipgloss.NewStyle().Foreground(mutedColor).Render(fmt.Sprintf("  • %s (%.2f)", pattern.Type, pattern.Score)) + "\n")
+		}
+	}
+
 	if llmUsed && llmPattern != nil {
 		metricsContent.WriteString("\n")
 		llmVerdict := lipgloss.NewStyle().Foreground(secondaryColor).Render("LLM Judge:")
@@ -220,9 +227,9 @@ func (m model) viewResults() string {
 				metricsContent.WriteString(lipgloss.NewStyle().Foreground(mutedColor).Render(fmt.Sprintf("    \"%s\"\n", reasoning)))
 			}
 		}
-	} else if m.enableLLM && m.llmProvider != "none" {
+	} else if m.enableLLM && m.llmProvider != "none" && len(m.result.Overridden) == 0 {
 		metricsContent.WriteString("\n")
-		modeNames := []string{"always", "when uncertain", "when safe"}
+		modeNames := []string{"always", "when uncertain", "when safe", "when unsafe"}
 		llmSkipped := lipgloss.NewStyle().Foreground(mutedColor).Render(fmt.Sprintf("LLM not consulted (runs %s)", modeNames[m.llmMode]))
 		metricsContent.WriteString(llmSkipped + "\n")
 	}
@@ -290,7 +297,7 @@ func (m model) viewSettings() string {
 	content.WriteString("LLM Judge:\n")
 	content.WriteString(selector(12, fmt.Sprintf("  Enable               %s", toggle(m.enableLLM))) + "\n")
 
-	modeNames := []string{"Always", "Conditional", "Fallback"}
+	modeNames := []string{"Always", "Conditional", "Fallback", "Verify"}
 	modeName := modeNames[m.llmMode]
 	content.WriteString(selector(13, fmt.Sprintf("  Mode                 %s", modeName)) + "\n")
 
@@ -316,6 +323,21 @@ func (m model) viewSettings() string {
 			if modelName == "" {
 				modelName = "anthropic/claude-sonnet-4.5 (default)"
 			}
+		case "anthropic":
+			modelName = os.Getenv("ANTHROPIC_MODEL")
+			if modelName == "" {
+				modelName = "claude-sonnet-4-5 (default)"
+			}
+		case "azure":
+			modelName = os.Getenv("AZURE_OPENAI_DEPLOYMENT")
+			if modelName == "" {
+				modelName = "gpt-4o (default deployment)"
+			}
+		case "gemini":
+			modelName = os.Getenv("GEMINI_MODEL")
+			if modelName == "" {
+				modelName = "gemini-2.5-flash (default)"
+			}
 		case "ollama":
 			modelName = os.Getenv("OLLAMA_MODEL")
 			if modelName == "" {
@@ -463,6 +485,12 @@ func capitalizeProviderName(p string) string {
 		return "OpenAI"
 	case "openrouter":
 		return "OpenRouter"
+	case "anthropic":
+		return "Anthropic"
+	case "azure":
+		return "Azure OpenAI"
+	case "gemini":
+		return "Gemini"
 	case "ollama":
 		return "Ollama"
 	case "none":
@@ -0,0 +1,114 @@
+// Package dataset loads labeled prompt injection datasets in JSON, JSONL and
+// CSV, maps arbitrary field names onto Sample, normalizes labels, removes
+// duplicates and splits samples into train and test sets.
+package dataset
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	LabelAttack = "attack"
+	LabelSafe   = "safe"
+)
+
+// Sample is one labeled input in the benchmarks/testdata format.
+type Sample struct {
+	ID       string `json:"id"`
+	Input    string `json:"input"`
+	Label    string `json:"label"`    // "attack" or "safe"
+	Category string `json:"category"` // e.g. "role_injection"
+	Notes    string `json:"notes"`
+}
+
+// IsAttack reports whether the sample is labeled as an attack.
+func (s Sample) IsAttack() bool {
+	return s.Label == LabelAttack
+}
+
+// Labeled reports whether the sample has a normalized attack or safe label.
+func (s Sample) Labeled() bool {
+	return s.Label == LabelAttack || s.Label == LabelSafe
+}
+
+// Dataset is the on-disk JSON schema used by benchmarks/testdata.
+type Dataset struct {
+	Version string   `json:"version"`
+	Samples []Sample `json:"samples"`
+}
+
+// Format is the file format of a dataset.
+type Format string
+
+const (
+	FormatJSON  Format = "json"
+	FormatJSONL Format = "jsonl"
+	FormatCSV   Format = "csv"
+)
+
+// FormatFromPath picks the format by file extension, defaulting to JSON.
+func FormatFromPath(path string) Format {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jsonl", ".ndjson":
+		return FormatJSONL
+	case ".csv":
+		return FormatCSV
+	}
+	return FormatJSON
+}
+
+// Mapping names the source field (JSON key or CSV column) for each Sample field.
+// JSON keys may be dotted paths into nested objects, e.g. "meta.label".
+type Mapping struct {
+	ID       string
+	Input    string
+	Label    string
+	Category string
+	Notes    string
+}
+
+// DefaultMapping matches the benchmarks/testdata schema.
+var DefaultMapping = Mapping{
+	ID:       "id",
+	Input:    "input",
+	Label:    "label",
+	Category: "category",
+	Notes:    "notes",
+}
+
+// ParseMapping builds a Mapping from sample field names to source fields,
+// e.g. {"input": "prompt", "label": "is_injection"}. Unset fields keep their default.
+func ParseMapping(fields map[string]string) (Mapping, error) {
+	m := DefaultMapping
+	for field, source := range fields {
+		switch strings.ToLower(strings.TrimSpace(field)) {
+		case "id":
+			m.ID = source
+		case "input":
+			m.Input = source
+		case "label":
+			m.Label = source
+		case "category":
+			m.Category = source
+		case "notes":
+			m.Notes = source
+		default:
+			return Mapping{}, fmt.Errorf("unknown sample field %q (want id, input, label, category or notes)", field)
+		}
+	}
+	return m, nil
+}
+
+// NormalizeLabel maps common label spellings to "attack" or "safe".
+// Unknown labels are returned unchanged.
+func NormalizeLabel(label string) string {
+	switch strings.ToLower(strings.TrimSpace(label)) {
+	case "attack", "injection", "prompt_injection", "malicious", "unsafe", "jailbreak", "1", "true", "yes":
+		return LabelAttack
+	case "safe", "benign", "legitimate", "0", "false", "no":
+		return LabelSafe
+	}
+	return label
+}
@@ -0,0 +1,36 @@
+package dataset
+
+import "strings"
+
+// DedupStats reports what Dedup removed.
+type DedupStats struct {
+	Duplicates int // samples dropped because an earlier sample had the same input
+	Conflicts  int // of those, how many disagreed with the kept sample's label
+}
+
+// Dedup drops samples whose input matches an earlier one, ignoring case and
+// whitespace differences. The first occurrence is kept.
+func Dedup(samples []Sample) ([]Sample, DedupStats) {
+	var stats DedupStats
+	seen := make(map[string]string, len(samples))
+	unique := make([]Sample, 0, len(samples))
+
+	for _, s := range samples {
+		key := dedupKey(s.Input)
+		if label, ok := seen[key]; ok {
+			stats.Duplicates++
+			if label != s.Label {
+				stats.Conflicts++
+			}
+			continue
+		}
+		seen[key] = s.Label
+		unique = append(unique, s)
+	}
+
+	return unique, stats
+}
+
+func dedupKey(input string) string {
+	return strings.Join(strings.Fields(strings.ToLower(input)), " ")
+}
@@ -0,0 +1,298 @@
+package dataset
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+type loader struct {
+	format       Format
+	mapping      Mapping
+	labels       map[string]string
+	defaultLabel string
+}
+
+// Option configures how datasets are loaded.
+type Option func(*loader)
+
+// WithFormat overrides format detection by file extension.
+func WithFormat(format Format) Option {
+	return func(l *loader) {
+		l.format = format
+	}
+}
+
+// WithMapping sets the source field for each Sample field.
+// Empty fields keep their DefaultMapping name.
+func WithMapping(m Mapping) Option {
+	return func(l *loader) {
+		if m.ID != "" {
+			l.mapping.ID = m.ID
+		}
+		if m.Input != "" {
+			l.mapping.Input = m.Input
+		}
+		if m.Label != "" {
+			l.mapping.Label = m.Label
+		}
+		if m.Category != "" {
+			l.mapping.Category = m.Category
+		}
+		if m.Notes != "" {
+			l.mapping.Notes = m.Notes
+		}
+	}
+}
+
+// WithLabels adds dataset-specific label values, e.g. {"jailbreak_v2": "attack", "2": "safe"}.
+// Keys are matched case-insensitively and take precedence over NormalizeLabel.
+// Entries whose value is not "attack" or "safe" are ignored.
+func WithLabels(labels map[string]string) Option {
+	return func(l *loader) {
+		for from, to := range labels {
+			to = NormalizeLabel(to)
+			if to != LabelAttack && to != LabelSafe {
+				continue
+			}
+			l.labels[strings.ToLower(strings.TrimSpace(from))] = to
+		}
+	}
+}
+
+// WithDefaultLabel labels samples whose label field is missing or empty,
+// e.g. LabelAttack for a plain list of jailbreak prompts.
+func WithDefaultLabel(label string) Option {
+	return func(l *loader) {
+		l.defaultLabel = NormalizeLabel(label)
+	}
+}
+
+// Load reads one dataset. The format is picked by extension unless WithFormat is given:
+// .json (an object with a samples array, or a plain array of records),
+// .jsonl/.ndjson (one record per line) or .csv (header row naming the columns).
+// Records without an input are skipped; samples without an ID get "file:N".
+func Load(path string, opts ...Option) ([]Sample, error) {
+	l := &loader{
+		format:  FormatFromPath(path),
+		mapping: DefaultMapping,
+		labels:  make(map[string]string),
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	var records []map[string]string
+	var err error
+	switch l.format {
+	case FormatJSONL:
+		records, err = l.readJSONL(path)
+	case FormatCSV:
+		records, err = l.readCSV(path)
+	case FormatJSON:
+		records, err = l.readJSON(path)
+	default:
+		err = fmt.Errorf("unknown format %q", l.format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load dataset %s: %w", path, err)
+	}
+
+	samples := make([]Sample, 0, len(records))
+	for i, r := range records {
+		s := Sample{
+			ID:       r["id"],
+			Input:    r["input"],
+			Label:    l.label(r["label"]),
+			Category: r["category"],
+			Notes:    r["notes"],
+		}
+		if strings.TrimSpace(s.Input) == "" {
+			continue
+		}
+		if s.ID == "" {
+			s.ID = fmt.Sprintf("%s:%d", filepath.Base(path), i+1)
+		}
+		samples = append(samples, s)
+	}
+	return samples, nil
+}
+
+// LoadAll reads and concatenates several datasets with the same options.
+func LoadAll(paths []string, opts ...Option) ([]Sample, error) {
+	var samples []Sample
+	for _, path := range paths {
+		loaded, err := Load(path, opts...)
+		if err != nil {
+			return nil, err
+		}
+		samples = append(samples, loaded...)
+	}
+	return samples, nil
+}
+
+func (l *loader) label(raw string) string {
+	if strings.TrimSpace(raw) == "" {
+		return l.defaultLabel
+	}
+	if mapped, ok := l.labels[strings.ToLower(strings.TrimSpace(raw))]; ok {
+		return mapped
+	}
+	return NormalizeLabel(raw)
+}
+
+// fields returns the sample field names paired with their source field.
+func (l *loader) fields() map[string]string {
+	return map[string]string{
+		"id":       l.mapping.ID,
+		"input":    l.mapping.Input,
+		"label":    l.mapping.Label,
+		"category": l.mapping.Category,
+		"notes":    l.mapping.Notes,
+	}
+}
+
+func (l *loader) readJSON(path string) ([]map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw []map[string]any
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		err = json.Unmarshal(trimmed, &raw)
+	} else {
+		var ds struct {
+			Samples []map[string]any `json:"samples"`
+		}
+		err = json.Unmarshal(trimmed, &ds)
+		raw = ds.Samples
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]map[string]string, len(raw))
+	for i, obj := range raw {
+		records[i] = l.fromObject(obj)
+	}
+	return records, nil
+}
+
+func (l *loader) readJSONL(path string) ([]map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var records []map[string]string
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var obj map[string]any
+		if err := json.Unmarshal([]byte(text), &obj); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		records = append(records, l.fromObject(obj))
+	}
+	return records, scanner.Err()
+}
+
+func (l *loader) readCSV(path string) ([]map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	columns := map[string]int{}
+	for i, name := range rows[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns[strings.ToLower(l.mapping.Input)]; !ok {
+		return nil, fmt.Errorf("CSV header must contain an %q column", l.mapping.Input)
+	}
+
+	fields := l.fields()
+	records := make([]map[string]string, 0, len(rows)-1)
+	for _, row := range rows[1:] {
+		r := make(map[string]string, len(fields))
+		for field, source := range fields {
+			if i, ok := columns[strings.ToLower(source)]; ok && i < len(row) {
+				r[field] = row[i]
+			}
+		}
+		records = append(records, r)
+	}
+	return records, nil
+}
+
+func (l *loader) fromObject(obj map[string]any) map[string]string {
+	fields := l.fields()
+	r := make(map[string]string, len(fields))
+	for field, source := range fields {
+		if v, ok := lookup(obj, source); ok {
+			r[field] = stringify(v)
+		}
+	}
+	return r
+}
+
+// lookup resolves a key or dotted path. An exact key match wins over a path,
+// so keys that themselves contain dots still work.
+func lookup(obj map[string]any, path string) (any, bool) {
+	if v, ok := obj[path]; ok {
+		return v, true
+	}
+	head, rest, found := strings.Cut(path, ".")
+	if !found {
+		return nil, false
+	}
+	nested, ok := obj[head].(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	return lookup(nested, rest)
+}
+
+func stringify(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case bool:
+		return strconv.FormatBool(val)
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
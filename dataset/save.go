@@ -0,0 +1,55 @@
+package dataset
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// Save writes samples in the format picked by the path's extension, using the
+// default field names so the file loads back without a mapping.
+func Save(path string, samples []Sample) error {
+	if dir := filepath.Dir(path); dir != "." && dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if samples == nil {
+		samples = []Sample{}
+	}
+
+	switch FormatFromPath(path) {
+	case FormatJSONL:
+		encoder := json.NewEncoder(file)
+		for _, s := range samples {
+			if err := encoder.Encode(s); err != nil {
+				return err
+			}
+		}
+		return nil
+	case FormatCSV:
+		writer := csv.NewWriter(file)
+		if err := writer.Write([]string{"id", "input", "label", "category", "notes"}); err != nil {
+			return err
+		}
+		for _, s := range samples {
+			if err := writer.Write([]string{s.ID, s.Input, s.Label, s.Category, s.Notes}); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	}
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(Dataset{Version: "1.0", Samples: samples})
+}
@@ -0,0 +1,43 @@
+package dataset
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Split shuffles samples with the given seed and holds out testFraction of
+// them (0.0-1.0), stratified by label so both sets keep the same attack/safe ratio.
+// Each label with at least two samples contributes at least one to each side.
+func Split(samples []Sample, testFraction float64, seed int64) (train, test []Sample) {
+	if testFraction <= 0 {
+		return append([]Sample(nil), samples...), nil
+	}
+	if testFraction >= 1 {
+		return nil, append([]Sample(nil), samples...)
+	}
+
+	// Group by label in first-seen order so the result only depends on the seed
+	var order []string
+	groups := make(map[string][]Sample)
+	for _, s := range samples {
+		if _, ok := groups[s.Label]; !ok {
+			order = append(order, s.Label)
+		}
+		groups[s.Label] = append(groups[s.Label], s)
+	}
+
+	rng := rand.New(rand.NewSource(seed))
+	for _, label := range order {
+		group := groups[label]
+		rng.Shuffle(len(group), func(i, j int) { group[i], group[j] = group[j], group[i] })
+
+		n := int(math.Round(float64(len(group)) * testFraction))
+		if len(group) >= 2 {
+			n = max(1, min(n, len(group)-1))
+		}
+		test = append(test, group[:n]...)
+		train = append(train, group[n:]...)
+	}
+
+	return train, test
+}
@@ -0,0 +1,73 @@
+package dataset
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func makeSamples(attacks, benign int) []Sample {
+	var samples []Sample
+	for i := 0; i < attacks; i++ {
+		samples = append(samples, Sample{ID: fmt.Sprintf("atk_%d", i), Input: fmt.Sprintf("attack %d", i), Label: LabelAttack})
+	}
+	for i := 0; i < benign; i++ {
+		samples = append(samples, Sample{ID: fmt.Sprintf("ben_%d", i), Input: fmt.Sprintf("benign %d", i), Label: LabelSafe})
+	}
+	return samples
+}
+
+func countLabel(samples []Sample, label string) int {
+	n := 0
+	for _, s := range samples {
+		if s.Label == label {
+			n++
+		}
+	}
+	return n
+}
+
+func TestSplit_Stratified(t *testing.T) {
+	samples := makeSamples(20, 80)
+
+	train, test := Split(samples, 0.25, 1)
+	assert.Len(t, train, 75)
+	assert.Len(t, test, 25)
+	assert.Equal(t, 5, countLabel(test, LabelAttack))
+	assert.Equal(t, 20, countLabel(test, LabelSafe))
+
+	seen := make(map[string]bool)
+	for _, s := range append(train, test...) {
+		assert.False(t, seen[s.ID], "sample %s appears twice", s.ID)
+		seen[s.ID] = true
+	}
+	assert.Len(t, seen, len(samples))
+}
+
+func TestSplit_Deterministic(t *testing.T) {
+	_, a := Split(makeSamples(10, 10), 0.3, 42)
+	_, b := Split(makeSamples(10, 10), 0.3, 42)
+	_, c := Split(makeSamples(10, 10), 0.3, 7)
+
+	assert.Equal(t, a, b)
+	assert.NotEqual(t, a, c)
+}
+
+func TestSplit_SmallGroups(t *testing.T) {
+	train, test := Split(makeSamples(2, 50), 0.1, 1)
+	assert.Equal(t, 1, countLabel(test, LabelAttack), "each side keeps at least one attack")
+	assert.Equal(t, 1, countLabel(train, LabelAttack))
+}
+
+func TestSplit_Bounds(t *testing.T) {
+	samples := makeSamples(3, 3)
+
+	train, test := Split(samples, 0, 1)
+	assert.Len(t, train, 6)
+	assert.Empty(t, test)
+
+	train, test = Split(samples, 1, 1)
+	assert.Empty(t, train)
+	assert.Len(t, test, 6)
+}
@@ -0,0 +1,288 @@
+package detector
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+)
+
+const (
+	// CalibrationPlatt fits sigmoid(A*score + B) to the labels.
+	CalibrationPlatt = "platt"
+
+	// CalibrationIsotonic fits a monotonic step function (pool adjacent violators).
+	CalibrationIsotonic = "isotonic"
+)
+
+// Calibration maps a raw risk score to a calibrated attack probability.
+// Produced by FitCalibration (or `go-promptguard calibrate --fit`) and stored as JSON.
+type Calibration struct {
+	Method string `json:"method"`
+
+	// Platt scaling
+	A float64 `json:"a,omitempty"`
+	B float64 `json:"b,omitempty"`
+
+	// Isotonic regression breakpoints, sorted by score
+	Scores        []float64 `json:"scores,omitempty"`
+	Probabilities []float64 `json:"probabilities,omitempty"`
+}
+
+// FitCalibration fits a score-to-probability mapping from raw risk scores and labels (true = attack).
+func FitCalibration(method string, scores []float64, labels []bool) (*Calibration, error) {
+	if len(scores) == 0 || len(scores) != len(labels) {
+		return nil, fmt.Errorf("need the same non-zero number of scores and labels, got %d and %d", len(scores), len(labels))
+	}
+
+	switch method {
+	case CalibrationPlatt:
+		return fitPlatt(scores, labels), nil
+	case CalibrationIsotonic:
+		return fitIsotonic(scores, labels), nil
+	default:
+		return nil, fmt.Errorf("unknown calibration method: %q", method)
+	}
+}
+
+// LoadCalibration reads a calibration mapping from a JSON file.
+func LoadCalibration(path string) (*Calibration, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read calibration: %w", err)
+	}
+
+	var c Calibration
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse calibration: %w", err)
+	}
+
+	switch c.Method {
+	case CalibrationPlatt:
+	case CalibrationIsotonic:
+		if len(c.Scores) == 0 || len(c.Scores) != len(c.Probabilities) {
+			return nil, fmt.Errorf("isotonic calibration needs matching scores and probabilities")
+		}
+	default:
+		return nil, fmt.Errorf("unknown calibration method: %q", c.Method)
+	}
+	return &c, nil
+}
+
+// Save writes the calibration as indented JSON.
+func (c *Calibration) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// Apply returns the calibrated probability (0.0-1.0) for a raw risk score.
+func (c *Calibration) Apply(score float64) float64 {
+	switch c.Method {
+	case CalibrationPlatt:
+		return sigmoid(c.A*score + c.B)
+	case CalibrationIsotonic:
+		n := len(c.Scores)
+		if n == 0 {
+			return score
+		}
+		if score <= c.Scores[0] {
+			return c.Probabilities[0]
+		}
+		if score >= c.Scores[n-1] {
+			return c.Probabilities[n-1]
+		}
+		// Linear interpolation between the surrounding breakpoints
+		i := sort.SearchFloat64s(c.Scores, score)
+		x0, x1 := c.Scores[i-1], c.Scores[i]
+		y0, y1 := c.Probabilities[i-1], c.Probabilities[i]
+		if x1 == x0 {
+			return y1
+		}
+		return y0 + (y1-y0)*(score-x0)/(x1-x0)
+	}
+	return score
+}
+
+// fitPlatt fits A and B with Newton's method on the log loss, using Platt's
+// smoothed targets so a perfectly separated dataset doesn't diverge.
+func fitPlatt(scores []float64, labels []bool) *Calibration {
+	positives, negatives := 0, 0
+	for _, l := range labels {
+		if l {
+			positives++
+		} else {
+			negatives++
+		}
+	}
+	hiTarget := (float64(positives) + 1) / (float64(positives) + 2)
+	loTarget := 1 / (float64(negatives) + 2)
+
+	a, b := 1.0, 0.0
+	for iter := 0; iter < 100; iter++ {
+		var gA, gB, hAA, hAB, hBB float64
+		for i, s := range scores {
+			t := loTarget
+			if labels[i] {
+				t = hiTarget
+			}
+			p := sigmoid(a*s + b)
+			d := p - t
+			w := p * (1 - p)
+			gA += d * s
+			gB += d
+			hAA += w * s * s
+			hAB += w * s
+			hBB += w
+		}
+		// Small ridge keeps the Hessian invertible
+		hAA += 1e-9
+		hBB += 1e-9
+
+		det := hAA*hBB - hAB*hAB
+		if det == 0 {
+			break
+		}
+		stepA := (hBB*gA - hAB*gB) / det
+		stepB := (hAA*gB - hAB*gA) / det
+		a -= stepA
+		b -= stepB
+
+		if math.Abs(stepA) < 1e-9 && math.Abs(stepB) < 1e-9 {
+			break
+		}
+	}
+
+	return &Calibration{Method: CalibrationPlatt, A: a, B: b}
+}
+
+// fitIsotonic fits a non-decreasing step function with pool adjacent violators.
+// Each pooled block becomes one breakpoint at its mean score.
+func fitIsotonic(scores []float64, labels []bool) *Calibration {
+	type block struct {
+		sumScore, sumLabel, count, maxScore float64
+	}
+	merge := func(a, b block) block {
+		return block{
+			sumScore: a.sumScore + b.sumScore,
+			sumLabel: a.sumLabel + b.sumLabel,
+			count:    a.count + b.count,
+			maxScore: math.Max(a.maxScore, b.maxScore),
+		}
+	}
+
+	idx := make([]int, len(scores))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.Slice(idx, func(a, b int) bool { return scores[idx[a]] < scores[idx[b]] })
+
+	var blocks []block
+	for _, i := range idx {
+		next := block{sumScore: scores[i], sumLabel: label(labels[i]), count: 1, maxScore: scores[i]}
+
+		// Identical scores must share a block, otherwise the mapping isn't a function
+		if n := len(blocks); n > 0 && blocks[n-1].maxScore == scores[i] {
+			blocks[n-1] = merge(blocks[n-1], next)
+		} else {
+			blocks = append(blocks, next)
+		}
+
+		// Pool while the last block is lower than the one before it, or equal to it
+		// (equal neighbours would only add redundant breakpoints)
+		for len(blocks) > 1 {
+			n := len(blocks)
+			prev, cur := blocks[n-2], blocks[n-1]
+			if prev.sumLabel/prev.count < cur.sumLabel/cur.count {
+				break
+			}
+			blocks[n-2] = merge(prev, cur)
+			blocks = blocks[:n-1]
+		}
+	}
+
+	c := &Calibration{Method: CalibrationIsotonic}
+	for _, b := range blocks {
+		c.Scores = append(c.Scores, round(b.sumScore/b.count, 4))
+		c.Probabilities = append(c.Probabilities, round(b.sumLabel/b.count, 4))
+	}
+	return c
+}
+
+// ThresholdPoint holds classification metrics at a single threshold.
+// Precision, Recall, FPR and F1 are fractions in 0.0-1.0.
+type ThresholdPoint struct {
+	Threshold float64 `json:"threshold"`
+	TP        int     `json:"tp"`
+	FP        int     `json:"fp"`
+	TN        int     `json:"tn"`
+	FN        int     `json:"fn"`
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+	FPR       float64 `json:"fpr"`
+	F1        float64 `json:"f1"`
+}
+
+// SweepThresholds computes precision, recall and false-positive rate for thresholds
+// from 0.0 to 1.0 in the given step. An input is flagged when score >= threshold,
+// matching MultiDetector.
+func SweepThresholds(scores []float64, labels []bool, step float64) []ThresholdPoint {
+	if step <= 0 || step > 1 {
+		step = 0.05
+	}
+
+	var points []ThresholdPoint
+	steps := int(math.Round(1.0 / step))
+	for k := 0; k <= steps; k++ {
+		t := round(float64(k)*step, 4)
+		p := ThresholdPoint{Threshold: t}
+		for i, s := range scores {
+			flagged := s >= t
+			switch {
+			case flagged && labels[i]:
+				p.TP++
+			case flagged && !labels[i]:
+				p.FP++
+			case !flagged && !labels[i]:
+				p.TN++
+			default:
+				p.FN++
+			}
+		}
+		if p.TP+p.FP > 0 {
+			p.Precision = float64(p.TP) / float64(p.TP+p.FP)
+		}
+		if p.TP+p.FN > 0 {
+			p.Recall = float64(p.TP) / float64(p.TP+p.FN)
+		}
+		if p.FP+p.TN > 0 {
+			p.FPR = float64(p.FP) / float64(p.FP+p.TN)
+		}
+		if p.Precision+p.Recall > 0 {
+			p.F1 = 2 * p.Precision * p.Recall / (p.Precision + p.Recall)
+		}
+		points = append(points, p)
+	}
+	return points
+}
+
+// RecommendThreshold picks the threshold with the highest recall whose false-positive
+// rate stays at or below targetFPR. Ties go to the higher threshold.
+// Returns false if no threshold meets the target.
+func RecommendThreshold(points []ThresholdPoint, targetFPR float64) (ThresholdPoint, bool) {
+	var best ThresholdPoint
+	found := false
+	for _, p := range points {
+		if p.FPR > targetFPR {
+			continue
+		}
+		if !found || p.Recall > best.Recall || (p.Recall == best.Recall && p.Threshold > best.Threshold) {
+			best = p
+			found = true
+		}
+	}
+	return best, found
+}
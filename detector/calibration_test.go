@@ -0,0 +1,112 @@
+package detector
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	calibrationScores = []float64{0.0, 0.0, 0.1, 0.2, 0.3, 0.5, 0.6, 0.7, 0.8, 0.9, 0.9, 1.0}
+	calibrationLabels = []bool{false, false, false, false, true, false, true, true, false, true, true, true}
+)
+
+func TestFitCalibration_Platt(t *testing.T) {
+	cal, err := FitCalibration(CalibrationPlatt, calibrationScores, calibrationLabels)
+	require.NoError(t, err)
+
+	assert.Greater(t, cal.A, 0.0, "higher scores should mean higher probability")
+	assert.Less(t, cal.Apply(0.1), cal.Apply(0.9))
+	assert.Less(t, cal.Apply(0.0), 0.5)
+	assert.Greater(t, cal.Apply(1.0), 0.5)
+}
+
+func TestFitCalibration_Isotonic(t *testing.T) {
+	cal, err := FitCalibration(CalibrationIsotonic, calibrationScores, calibrationLabels)
+	require.NoError(t, err)
+
+	require.Equal(t, len(cal.Scores), len(cal.Probabilities))
+	for i := 1; i < len(cal.Probabilities); i++ {
+		assert.Greater(t, cal.Probabilities[i], cal.Probabilities[i-1], "probabilities must increase")
+		assert.Greater(t, cal.Scores[i], cal.Scores[i-1], "breakpoints must be distinct")
+	}
+
+	assert.Equal(t, 0.0, cal.Apply(0.0))
+	assert.Equal(t, 1.0, cal.Apply(1.0))
+	mid := cal.Apply(0.65)
+	assert.Greater(t, mid, 0.0)
+	assert.Less(t, mid, 1.0)
+}
+
+func TestFitCalibration_Errors(t *testing.T) {
+	_, err := FitCalibration(CalibrationPlatt, nil, nil)
+	assert.Error(t, err)
+
+	_, err = FitCalibration("beta", calibrationScores, calibrationLabels)
+	assert.Error(t, err)
+}
+
+func TestCalibration_SaveLoad(t *testing.T) {
+	cal, err := FitCalibration(CalibrationIsotonic, calibrationScores, calibrationLabels)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "calibration.json")
+	require.NoError(t, cal.Save(path))
+
+	loaded, err := LoadCalibration(path)
+	require.NoError(t, err)
+	assert.Equal(t, cal.Apply(0.65), loaded.Apply(0.65))
+
+	bad := &Calibration{Method: CalibrationIsotonic}
+	require.NoError(t, bad.Save(path))
+	_, err = LoadCalibration(path)
+	assert.Error(t, err)
+}
+
+func TestSweepThresholds(t *testing.T) {
+	points := SweepThresholds(calibrationScores, calibrationLabels, 0.1)
+	require.Len(t, points, 11)
+
+	first := points[0]
+	assert.Equal(t, 0.0, first.Threshold)
+	assert.Equal(t, 1.0, first.Recall, "everything is flagged at threshold 0")
+	assert.Equal(t, 1.0, first.FPR)
+
+	last := points[len(points)-1]
+	assert.Equal(t, 1.0, last.Threshold)
+	assert.Equal(t, 1, last.TP)
+	assert.Equal(t, 0.0, last.FPR)
+
+	for _, p := range points {
+		assert.Equal(t, len(calibrationScores), p.TP+p.FP+p.TN+p.FN)
+	}
+}
+
+func TestRecommendThreshold(t *testing.T) {
+	points := SweepThresholds(calibrationScores, calibrationLabels, 0.1)
+
+	best, ok := RecommendThreshold(points, 0.0)
+	require.True(t, ok)
+	assert.Equal(t, 0.0, best.FPR)
+	assert.Equal(t, 0.9, best.Threshold)
+
+	best, ok = RecommendThreshold(points, 1.0)
+	require.True(t, ok)
+	assert.Equal(t, 1.0, best.Recall)
+
+	_, ok = RecommendThreshold(nil, 0.1)
+	assert.False(t, ok)
+}
+
+func TestMultiDetector_WithCalibrator(t *testing.T) {
+	// Maps every raw score to 0.99, so even safe inputs become unsafe at the default threshold
+	cal := &Calibration{Method: CalibrationIsotonic, Scores: []float64{0, 1}, Probabilities: []float64{0.99, 0.99}}
+	guard := New(WithCalibrator(cal))
+
+	result := guard.Detect(context.Background(), "What is the weather today?")
+	assert.Equal(t, 0.99, result.RiskScore)
+	assert.False(t, result.Safe)
+}
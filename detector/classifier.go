@@ -0,0 +1,135 @@
+package detector
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+)
+
+const (
+	// ModelLogistic is a logistic regression over standardized features.
+	ModelLogistic = "logistic"
+
+	// ModelStumps is a gradient-boosted ensemble of decision stumps.
+	ModelStumps = "stumps"
+)
+
+// Model is a trained classifier that turns a feature vector (see FeatureNames)
+// into the probability that the input is an attack.
+// Models are produced by TrainModel (or `go-promptguard train`) and stored as JSON.
+type Model struct {
+	Type     string   `json:"type"`
+	Features []string `json:"features"`
+
+	// Logistic regression
+	Mean    []float64 `json:"mean,omitempty"`
+	Scale   []float64 `json:"scale,omitempty"`
+	Weights []float64 `json:"weights,omitempty"`
+
+	// Gradient-boosted stumps
+	Stumps       []Stump `json:"stumps,omitempty"`
+	LearningRate float64 `json:"learning_rate,omitempty"`
+
+	Bias float64 `json:"bias"`
+}
+
+// Stump is a single-split decision tree used by ModelStumps.
+type Stump struct {
+	Feature   int     `json:"feature"`
+	Threshold float64 `json:"threshold"`
+	Left      float64 `json:"left"`  // value when feature <= threshold
+	Right     float64 `json:"right"` // value when feature > threshold
+}
+
+// LoadModel reads a trained model from a JSON file and checks it matches
+// the feature set of this version of the library.
+func LoadModel(path string) (*Model, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read model: %w", err)
+	}
+
+	var m Model
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse model: %w", err)
+	}
+
+	if err := m.validate(); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// Save writes the model as indented JSON.
+func (m *Model) Save(path string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// Predict returns the attack probability (0.0-1.0) for a feature vector.
+func (m *Model) Predict(features []float64) float64 {
+	z := m.Bias
+
+	switch m.Type {
+	case ModelLogistic:
+		for i, w := range m.Weights {
+			if i >= len(features) {
+				break
+			}
+			x := features[i] - m.Mean[i]
+			if m.Scale[i] > 0 {
+				x /= m.Scale[i]
+			}
+			z += w * x
+		}
+	case ModelStumps:
+		for _, s := range m.Stumps {
+			if s.Feature >= len(features) {
+				continue
+			}
+			if features[s.Feature] <= s.Threshold {
+				z += m.LearningRate * s.Left
+			} else {
+				z += m.LearningRate * s.Right
+			}
+		}
+	}
+
+	return sigmoid(z)
+}
+
+func (m *Model) validate() error {
+	names := FeatureNames()
+	if len(m.Features) != len(names) {
+		return fmt.Errorf("model has %d features, expected %d (trained with a different version?)", len(m.Features), len(names))
+	}
+	for i, name := range names {
+		if m.Features[i] != name {
+			return fmt.Errorf("model feature %d is %q, expected %q", i, m.Features[i], name)
+		}
+	}
+
+	switch m.Type {
+	case ModelLogistic:
+		if len(m.Weights) != len(names) || len(m.Mean) != len(names) || len(m.Scale) != len(names) {
+			return fmt.Errorf("logistic model weights do not match feature count")
+		}
+	case ModelStumps:
+		for _, s := range m.Stumps {
+			if s.Feature < 0 || s.Feature >= len(names) {
+				return fmt.Errorf("stump references unknown feature %d", s.Feature)
+			}
+		}
+	default:
+		return fmt.Errorf("unknown model type: %q", m.Type)
+	}
+	return nil
+}
+
+func sigmoid(z float64) float64 {
+	return 1.0 / (1.0 + math.Exp(-z))
+}
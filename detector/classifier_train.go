@@ -0,0 +1,359 @@
+package detector
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// TrainOptions controls model training. Zero values fall back to defaults.
+type TrainOptions struct {
+	// ModelLogistic or ModelStumps. Default: ModelLogistic.
+	Type string
+
+	// Gradient descent epochs (logistic) or boosting rounds (stumps).
+	// Default: 500 for logistic, 100 for stumps.
+	Iterations int
+
+	// Default: 0.1.
+	LearningRate float64
+
+	// L2 regularization strength (logistic only). Negative turns it off.
+	// Default: 0.01.
+	L2 float64
+
+	// Seed for fold shuffling in CrossValidate.
+	// Default: 1.
+	Seed int64
+}
+
+func (o TrainOptions) withDefaults() TrainOptions {
+	if o.Type == "" {
+		o.Type = ModelLogistic
+	}
+	if o.Iterations <= 0 {
+		o.Iterations = 500
+		if o.Type == ModelStumps {
+			o.Iterations = 100
+		}
+	}
+	if o.LearningRate <= 0 {
+		o.LearningRate = 0.1
+	}
+	if o.L2 == 0 {
+		o.L2 = 0.01
+	} else if o.L2 < 0 {
+		o.L2 = 0
+	}
+	if o.Seed == 0 {
+		o.Seed = 1
+	}
+	return o
+}
+
+// TrainModel fits a classifier on feature vectors (see MultiDetector.Features)
+// and labels (true = attack).
+func TrainModel(x [][]float64, y []bool, opts TrainOptions) (*Model, error) {
+	opts = opts.withDefaults()
+
+	if len(x) == 0 || len(x) != len(y) {
+		return nil, fmt.Errorf("need the same non-zero number of samples and labels, got %d and %d", len(x), len(y))
+	}
+	width := len(FeatureNames())
+	for i, row := range x {
+		if len(row) != width {
+			return nil, fmt.Errorf("sample %d has %d features, expected %d", i, len(row), width)
+		}
+	}
+
+	switch opts.Type {
+	case ModelLogistic:
+		return trainLogistic(x, y, opts), nil
+	case ModelStumps:
+		return trainStumps(x, y, opts), nil
+	default:
+		return nil, fmt.Errorf("unknown model type: %q", opts.Type)
+	}
+}
+
+// trainLogistic fits a logistic regression with batch gradient descent and L2 regularization.
+// Features are standardized first so one learning rate suits all of them.
+func trainLogistic(x [][]float64, y []bool, opts TrainOptions) *Model {
+	n, width := len(x), len(x[0])
+
+	mean := make([]float64, width)
+	scale := make([]float64, width)
+	for _, row := range x {
+		for j, v := range row {
+			mean[j] += v
+		}
+	}
+	for j := range mean {
+		mean[j] /= float64(n)
+	}
+	for _, row := range x {
+		for j, v := range row {
+			scale[j] += (v - mean[j]) * (v - mean[j])
+		}
+	}
+	for j := range scale {
+		scale[j] = math.Sqrt(scale[j] / float64(n))
+	}
+
+	std := make([][]float64, n)
+	for i, row := range x {
+		std[i] = make([]float64, width)
+		for j, v := range row {
+			if scale[j] > 0 {
+				std[i][j] = (v - mean[j]) / scale[j]
+			}
+		}
+	}
+
+	weights := make([]float64, width)
+	bias := 0.0
+	grad := make([]float64, width)
+
+	for epoch := 0; epoch < opts.Iterations; epoch++ {
+		for j := range grad {
+			grad[j] = 0
+		}
+		gradBias := 0.0
+
+		for i, row := range std {
+			z := bias
+			for j, v := range row {
+				z += weights[j] * v
+			}
+			diff := sigmoid(z) - label(y[i])
+			for j, v := range row {
+				grad[j] += diff * v
+			}
+			gradBias += diff
+		}
+
+		for j := range weights {
+			weights[j] -= opts.LearningRate * (grad[j]/float64(n) + opts.L2*weights[j])
+		}
+		bias -= opts.LearningRate * gradBias / float64(n)
+	}
+
+	return &Model{
+		Type:     ModelLogistic,
+		Features: FeatureNames(),
+		Mean:     mean,
+		Scale:    scale,
+		Weights:  weights,
+		Bias:     bias,
+	}
+}
+
+// trainStumps fits gradient-boosted decision stumps on the logistic loss.
+// Each round picks the split that best fits the current gradients and uses a
+// Newton step for the leaf values.
+func trainStumps(x [][]float64, y []bool, opts TrainOptions) *Model {
+	n, width := len(x), len(x[0])
+
+	positives := 0
+	for _, v := range y {
+		if v {
+			positives++
+		}
+	}
+	// Start from the log-odds of the base rate, clamped so a single-class dataset stays finite.
+	base := (float64(positives) + 0.5) / (float64(n) + 1.0)
+	bias := math.Log(base / (1 - base))
+
+	candidates := make([][]float64, width)
+	for j := 0; j < width; j++ {
+		candidates[j] = splitCandidates(x, j, 32)
+	}
+
+	margin := make([]float64, n)
+	for i := range margin {
+		margin[i] = bias
+	}
+
+	model := &Model{
+		Type:         ModelStumps,
+		Features:     FeatureNames(),
+		LearningRate: opts.LearningRate,
+		Bias:         bias,
+	}
+
+	grad := make([]float64, n)
+	hess := make([]float64, n)
+
+	for round := 0; round < opts.Iterations; round++ {
+		for i := range margin {
+			p := sigmoid(margin[i])
+			grad[i] = label(y[i]) - p
+			hess[i] = p * (1 - p)
+		}
+
+		best, found := bestStump(x, grad, hess, candidates)
+		if !found {
+			break
+		}
+
+		model.Stumps = append(model.Stumps, best)
+		for i, row := range x {
+			if row[best.Feature] <= best.Threshold {
+				margin[i] += opts.LearningRate * best.Left
+			} else {
+				margin[i] += opts.LearningRate * best.Right
+			}
+		}
+	}
+
+	return model
+}
+
+// bestStump finds the split with the largest gain over all features and candidate thresholds.
+func bestStump(x [][]float64, grad, hess []float64, candidates [][]float64) (Stump, bool) {
+	const lambda = 1.0 // leaf regularization, keeps tiny leaves from exploding
+
+	var best Stump
+	bestGain := 0.0
+	found := false
+
+	for j, thresholds := range candidates {
+		for _, t := range thresholds {
+			var gl, hl, gr, hr float64
+			for i, row := range x {
+				if row[j] <= t {
+					gl += grad[i]
+					hl += hess[i]
+				} else {
+					gr += grad[i]
+					hr += hess[i]
+				}
+			}
+			gain := gl*gl/(hl+lambda) + gr*gr/(hr+lambda)
+			if gain > bestGain {
+				bestGain = gain
+				best = Stump{
+					Feature:   j,
+					Threshold: t,
+					Left:      gl / (hl + lambda),
+					Right:     gr / (hr + lambda),
+				}
+				found = true
+			}
+		}
+	}
+
+	return best, found
+}
+
+// splitCandidates returns up to maxSplits midpoints between distinct values of feature j.
+func splitCandidates(x [][]float64, j, maxSplits int) []float64 {
+	values := make([]float64, 0, len(x))
+	seen := make(map[float64]bool)
+	for _, row := range x {
+		if !seen[row[j]] {
+			seen[row[j]] = true
+			values = append(values, row[j])
+		}
+	}
+	sort.Float64s(values)
+
+	if len(values) < 2 {
+		return nil
+	}
+
+	step := 1
+	if len(values)-1 > maxSplits {
+		step = (len(values) - 1 + maxSplits - 1) / maxSplits
+	}
+
+	splits := make([]float64, 0, maxSplits)
+	for i := 0; i+1 < len(values); i += step {
+		splits = append(splits, (values[i]+values[i+1])/2)
+	}
+	return splits
+}
+
+// CVMetrics summarizes k-fold cross-validation at a 0.5 probability cutoff.
+// Precision, Recall, F1 and Accuracy are percentages, like the benchmarks report.
+type CVMetrics struct {
+	Folds int
+	ConfusionMatrix
+	LogLoss float64
+}
+
+// CrossValidate runs stratified k-fold cross-validation and returns metrics pooled over all folds.
+func CrossValidate(x [][]float64, y []bool, folds int, opts TrainOptions) (CVMetrics, error) {
+	opts = opts.withDefaults()
+
+	if folds < 2 {
+		return CVMetrics{}, fmt.Errorf("need at least 2 folds, got %d", folds)
+	}
+	if len(x) < folds || len(x) != len(y) {
+		return CVMetrics{}, fmt.Errorf("need at least %d labeled samples, got %d", folds, len(x))
+	}
+
+	// Stratify: shuffle attacks and benign separately, then deal them round-robin into folds.
+	rng := rand.New(rand.NewSource(opts.Seed))
+	var attacks, benign []int
+	for i, v := range y {
+		if v {
+			attacks = append(attacks, i)
+		} else {
+			benign = append(benign, i)
+		}
+	}
+	rng.Shuffle(len(attacks), func(a, b int) { attacks[a], attacks[b] = attacks[b], attacks[a] })
+	rng.Shuffle(len(benign), func(a, b int) { benign[a], benign[b] = benign[b], benign[a] })
+
+	fold := make([]int, len(x))
+	for k, i := range append(attacks, benign...) {
+		fold[i] = k % folds
+	}
+
+	m := CVMetrics{Folds: folds}
+	lossSum := 0.0
+
+	for f := 0; f < folds; f++ {
+		var trainX [][]float64
+		var trainY []bool
+		var testIdx []int
+		for i := range x {
+			if fold[i] == f {
+				testIdx = append(testIdx, i)
+			} else {
+				trainX = append(trainX, x[i])
+				trainY = append(trainY, y[i])
+			}
+		}
+
+		model, err := TrainModel(trainX, trainY, opts)
+		if err != nil {
+			return CVMetrics{}, err
+		}
+
+		for _, i := range testIdx {
+			p := model.Predict(x[i])
+			m.Add(p >= 0.5, y[i])
+
+			p = math.Min(math.Max(p, 1e-15), 1-1e-15)
+			if y[i] {
+				lossSum -= math.Log(p)
+			} else {
+				lossSum -= math.Log(1 - p)
+			}
+		}
+	}
+
+	m.LogLoss = lossSum / float64(len(x))
+
+	return m, nil
+}
+
+func label(attack bool) float64 {
+	if attack {
+		return 1
+	}
+	return 0
+}
@@ -1,5 +1,7 @@
 package detector
 
+import "time"
+
 // DetectionMode represents the aggressiveness level of certain detectors.
 type DetectionMode int
 
@@ -10,6 +12,16 @@ const (
 	ModeAggressive
 )
 
+// FailurePolicy decides the verdict when detection could not finish.
+type FailurePolicy int
+
+const (
+	// FailOpen treats unfinished checks as safe (only what did run can flag the input).
+	FailOpen FailurePolicy = iota
+	// FailClosed treats unfinished checks as unsafe.
+	FailClosed
+)
+
 type Config struct {
 	// 0.0 to 1.0.
 	// Default: 0.7.
@@ -55,9 +67,55 @@ type Config struct {
 	// Default: nil (disabled).
 	LLMJudge LLMJudge
 
-	// Options: LLMAlways, LLMConditional, LLMFallback.
+	// Options: LLMAlways, LLMConditional, LLMFallback, LLMVerify.
 	// Default: LLMAlways.
 	LLMRunMode LLMRunMode
+
+	// Pattern score range (inclusive) in which LLMConditional runs the judge.
+	// Default: 0.5-0.7.
+	LLMBandLow  float64
+	LLMBandHigh float64
+
+	// Deadline for one judge call, retries and fallbacks included.
+	// Default: 0 (as long as the judge can take, see NewLLMDetector).
+	LLMTimeout time.Duration
+
+	// Judge confidence needed for LLMVerify to clear pattern detections.
+	// Default: 0.8.
+	LLMVerifyConfidence float64
+
+	// Share of inputs LLMShadow sends to the judge, 0.0 to 1.0.
+	// Default: 1.0.
+	ShadowSampleRate float64
+
+	// Pending shadow checks beyond this are dropped.
+	// Default: 100.
+	ShadowQueueSize int
+
+	// Goroutines judging shadow checks.
+	// Default: 2.
+	ShadowWorkers int
+
+	// Called from a shadow worker when the judge disagrees with the returned verdict.
+	// Default: nil.
+	ShadowHandler func(ShadowReport)
+
+	// Detector categories LLMVerify may clear, matched as pattern type prefixes,
+	// e.g. "entropy", "perplexity". Detections from other categories always stand.
+	// Default: nil (all categories).
+	LLMOverridable []string
+
+	// Trained classifier used as the final scorer instead of the weighted sum.
+	// Default: nil (weighted scoring).
+	Model *Model
+
+	// Score-to-probability mapping applied to the final risk score.
+	// Default: nil (raw scores).
+	Calibration *Calibration
+
+	// Verdict when the context is cancelled or the LLM judge fails.
+	// Default: FailOpen.
+	FailurePolicy FailurePolicy
 }
 
 type Option func(*Config)
@@ -79,6 +137,17 @@ func defaultConfig() Config {
 		MaxInputLength:            0,
 		LLMJudge:                  nil,
 		LLMRunMode:                LLMAlways,
+		LLMBandLow:                0.5,
+		LLMBandHigh:               0.7,
+		LLMVerifyConfidence:       0.8,
+		LLMOverridable:            nil,
+		ShadowSampleRate:          1.0,
+		ShadowQueueSize:           100,
+		ShadowWorkers:             2,
+		ShadowHandler:             nil,
+		Model:                     nil,
+		Calibration:               nil,
+		FailurePolicy:             FailOpen,
 	}
 }
 
@@ -92,6 +161,21 @@ func WithThreshold(threshold float64) Option {
 	}
 }
 
+// WithFailurePolicy sets the verdict for inputs whose check did not finish
+// because the context was cancelled or the LLM judge returned an error.
+//   - FailOpen (default): such inputs are safe unless a finished stage flagged them.
+//   - FailClosed: such inputs are unsafe. Use for high-security endpoints.
+//
+// Either way Result.Incomplete and Result.Errors record what did not finish,
+// and DetectE returns it as an error.
+func WithFailurePolicy(policy FailurePolicy) Option {
+	return func(c *Config) {
+		if policy == FailOpen || policy == FailClosed {
+			c.FailurePolicy = policy
+		}
+	}
+}
+
 // WithRoleInjection enables or disables role injection detection.
 func WithRoleInjection(enabled bool) Option {
 	return func(c *Config) {
@@ -203,11 +287,103 @@ func WithAllDetectors() Option {
 // WithLLM enables LLM-based detection with the specified judge and run mode.
 // Modes:
 //   - LLMAlways: Run on every input (most accurate, most expensive)
-//   - LLMConditional: Run only when pattern-based detectors are uncertain (0.5-0.7 score).
+//   - LLMConditional: Run only when pattern-based detectors are uncertain (0.5-0.7 score, see WithLLMBand).
 //   - LLMFallback: Run only when pattern-based detectors say safe (double-check negatives).
+//   - LLMVerify: Run only when pattern-based detectors say unsafe, and let a confident
+//     SAFE verdict clear their detections (double-check positives).
+//   - LLMShadow: Run in the background on a sample of inputs and report disagreements
+//     without changing the verdict (see WithShadowSampling, WithShadowHandler).
 func WithLLM(judge LLMJudge, mode LLMRunMode) Option {
 	return func(c *Config) {
 		c.LLMJudge = judge
 		c.LLMRunMode = mode
 	}
 }
+
+// WithLLMBand sets the pattern score range in which LLMConditional runs the judge.
+func WithLLMBand(low, high float64) Option {
+	return func(c *Config) {
+		if low >= 0.0 && high <= 1.0 && low <= high {
+			c.LLMBandLow = low
+			c.LLMBandHigh = high
+		}
+	}
+}
+
+// WithLLMStageTimeout sets the deadline for one judge call, retries and
+// fallbacks included. By default it is derived from the judge's own timeouts.
+func WithLLMStageTimeout(timeout time.Duration) Option {
+	return func(c *Config) {
+		if timeout > 0 {
+			c.LLMTimeout = timeout
+		}
+	}
+}
+
+// WithLLMVerifyConfidence sets how confident a SAFE verdict must be for LLMVerify
+// to clear pattern detections.
+func WithLLMVerifyConfidence(confidence float64) Option {
+	return func(c *Config) {
+		if confidence >= 0.0 && confidence <= 1.0 {
+			c.LLMVerifyConfidence = confidence
+		}
+	}
+}
+
+// WithShadowSampling sets the share of inputs LLMShadow sends to the judge,
+// e.g. 0.05 for 5% of traffic.
+func WithShadowSampling(rate float64) Option {
+	return func(c *Config) {
+		if rate >= 0.0 && rate <= 1.0 {
+			c.ShadowSampleRate = rate
+		}
+	}
+}
+
+// WithShadowQueue sets how many shadow checks may wait and how many run at once.
+// Checks that do not fit in the queue are dropped, so load never adds goroutines.
+func WithShadowQueue(size, workers int) Option {
+	return func(c *Config) {
+		if size > 0 && workers > 0 {
+			c.ShadowQueueSize = size
+			c.ShadowWorkers = workers
+		}
+	}
+}
+
+// WithShadowHandler sets the callback LLMShadow calls when the judge disagrees
+// with the verdict that was returned. It runs on a shadow worker, so slow
+// handlers slow down shadow judging (never Detect).
+func WithShadowHandler(handler func(ShadowReport)) Option {
+	return func(c *Config) {
+		c.ShadowHandler = handler
+	}
+}
+
+// WithLLMOverridable limits which detector categories LLMVerify may clear, matched
+// as prefixes of DetectedPattern.Type (e.g. "entropy", "perplexity", "token").
+// Detections from other categories keep the input unsafe whatever the judge says.
+func WithLLMOverridable(categories ...string) Option {
+	return func(c *Config) {
+		c.LLMOverridable = categories
+	}
+}
+
+// WithClassifier uses a trained classifier (see `go-promptguard train` and
+// LoadModel) as the final scorer. RiskScore becomes the model's attack probability.
+// Enable the same detectors and modes the model's training features came from;
+// `go-promptguard train` uses the defaults.
+func WithClassifier(m *Model) Option {
+	return func(c *Config) {
+		c.Model = m
+	}
+}
+
+// WithCalibrator applies a score-to-probability mapping (see `go-promptguard calibrate --fit`
+// and LoadCalibration) so RiskScore becomes a calibrated attack probability.
+// The threshold then applies to the calibrated value.
+func WithCalibrator(cal *Calibration) Option {
+	return func(c *Config) {
+		c.Calibration = cal
+	}
+}
@@ -0,0 +1,100 @@
+package detector
+
+import (
+	"context"
+	"math"
+	"strings"
+)
+
+// featureCategories lists the detector categories that contribute a score and a hit-count feature.
+// Order matters: trained models store weights positionally, so only ever append to this list.
+var featureCategories = []string{
+	"role_injection",
+	"prompt_leak",
+	"instruction_override",
+	"obfuscation",
+	"normalization",
+	"delimiter",
+	"entropy",
+	"perplexity",
+	"token",
+}
+
+// statisticalFeatures are raw text statistics computed directly from the input,
+// independent of whether any detector crossed its own threshold.
+var statisticalFeatures = []string{
+	"shannon_entropy",
+	"rare_bigram_ratio",
+	"non_alpha_ratio",
+	"special_char_ratio",
+	"digit_ratio",
+	"repetition_ratio",
+	"zero_width_count",
+	"script_count",
+	"log_length",
+	"decoded_variants",
+}
+
+// FeatureNames returns the names of the features produced by Features, in vector order.
+// "<category>_score" is the highest pattern score of that category, "<category>_hits"
+// the number of patterns it produced (capped at 5 and scaled to 0-1).
+func FeatureNames() []string {
+	names := make([]string, 0, len(featureCategories)*2+len(statisticalFeatures))
+	for _, c := range featureCategories {
+		names = append(names, c+"_score", c+"_hits")
+	}
+	return append(names, statisticalFeatures...)
+}
+
+// Features runs the enabled pattern-based detectors on the input and returns
+// its feature vector (see FeatureNames). The LLM judge and trained model are not used.
+// Returns nil if the context is cancelled before all detectors ran.
+func (md *MultiDetector) Features(ctx context.Context, input string) []float64 {
+	if md.config.MaxInputLength > 0 && len(input) > md.config.MaxInputLength {
+		input = input[:md.config.MaxInputLength]
+	}
+
+	pass, ok := md.runPatternDetectors(ctx, input)
+	if !ok {
+		return nil
+	}
+	return extractFeatures(input, pass.patterns)
+}
+
+// extractFeatures builds the feature vector from detector patterns and raw input statistics.
+// Patterns from the LLM judge are ignored.
+func extractFeatures(input string, patterns []DetectedPattern) []float64 {
+	features := make([]float64, 0, len(featureCategories)*2+len(statisticalFeatures))
+
+	for _, category := range featureCategories {
+		best := 0.0
+		hits := 0
+		for _, p := range patterns {
+			if !strings.HasPrefix(p.Type, category+"_") {
+				continue
+			}
+			hits++
+			if p.Score > best {
+				best = p.Score
+			}
+		}
+		features = append(features, best, float64(minInt(hits, 5))/5.0)
+	}
+
+	zeroWidth := countZeroWidthChars(input)
+
+	features = append(features,
+		calculateShannonEntropy(input)/8.0,
+		calculateRareBigramRatio(strings.ToLower(input)),
+		calculateNonAlphabeticRatio(input),
+		calculateSpecialCharRatio(input),
+		calculateDigitRatio(input),
+		calculateRepetitionRatio(input),
+		float64(minInt(zeroWidth, 10))/10.0,
+		float64(detectScriptMixing(input).scriptCount)/5.0,
+		math.Log1p(float64(len(input)))/10.0,
+		float64(len(Preprocess(input))-1)/3.0,
+	)
+
+	return features
+}
@@ -0,0 +1,81 @@
+package detector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	anthropicVersion   = "2023-06-01"
+	anthropicMaxTokens = 1024
+)
+
+// anthropicMessages is the Anthropic Messages API format: the system prompt is a
+// top-level field and the reply is a list of content blocks.
+type anthropicMessages struct{}
+
+func (anthropicMessages) newRequest(ctx context.Context, j *GenericLLMJudge, messages []chatMessage) (*http.Request, error) {
+	payload := map[string]interface{}{
+		"model":      j.model,
+		"system":     j.systemPrompt,
+		"messages":   messages,
+		"max_tokens": anthropicMaxTokens,
+	}
+	if j.temperature != nil {
+		payload["temperature"] = *j.temperature
+	}
+
+	req, err := newJSONRequest(ctx, j.endpoint, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("anthropic-version", anthropicVersion)
+	if j.apiKey != "" {
+		req.Header.Set("x-api-key", j.apiKey)
+	}
+	return req, nil
+}
+
+func (anthropicMessages) parseResponse(body []byte) (chatReply, error) {
+	var apiResp struct {
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+		StopReason string `json:"stop_reason"`
+		Usage      struct {
+			InputTokens  int `json:"input_tokens"`
+			OutputTokens int `json:"output_tokens"`
+		} `json:"usage"`
+	}
+
+	if err := json.Unmarshal(body, &apiResp); err != nil {
+		return chatReply{}, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	// Only text blocks carry the verdict; thinking and other block types are skipped.
+	var text strings.Builder
+	for _, block := range apiResp.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+
+	if text.Len() == 0 {
+		if apiResp.StopReason == "refusal" {
+			return chatReply{}, fmt.Errorf("LLM refused to classify the input")
+		}
+		return chatReply{}, fmt.Errorf("no response from LLM")
+	}
+
+	usage := TokenUsage{
+		PromptTokens:     apiResp.Usage.InputTokens,
+		CompletionTokens: apiResp.Usage.OutputTokens,
+		TotalTokens:      apiResp.Usage.InputTokens + apiResp.Usage.OutputTokens,
+	}
+	return chatReply{text: text.String(), usage: usage}, nil
+}
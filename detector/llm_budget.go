@@ -0,0 +1,401 @@
+package detector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrBudgetExhausted is returned without calling the judge once its daily budget is spent.
+var ErrBudgetExhausted = errors.New("LLM budget exhausted")
+
+// errBudgetFailClosed is ErrBudgetExhausted under BudgetFailClosed. The guard
+// blocks on it whatever its FailurePolicy.
+var errBudgetFailClosed = fmt.Errorf("%w, failing closed", ErrBudgetExhausted)
+
+// stageFailsClosed reports whether a stage error must block the input under
+// any FailurePolicy.
+func stageFailsClosed(errs []StageError) bool {
+	for _, e := range errs {
+		if errors.Is(e.Err, errBudgetFailClosed) {
+			return true
+		}
+	}
+	return false
+}
+
+// BudgetPolicy decides what a BudgetJudge does once its daily budget is spent.
+type BudgetPolicy int
+
+const (
+	// BudgetSkip returns ErrBudgetExhausted so the LLM stage is skipped. The guard
+	// then follows its FailurePolicy: patterns only with FailOpen, unsafe with FailClosed.
+	BudgetSkip BudgetPolicy = iota
+
+	// BudgetFailClosed returns an error wrapping ErrBudgetExhausted on which the
+	// guard marks every input unsafe and Incomplete, even with FailOpen, until the
+	// budget resets. Being an error, it is never cached as a verdict.
+	BudgetFailClosed
+
+	// BudgetFallback sends inputs to the fallback judge (see WithBudgetFallback).
+	BudgetFallback
+)
+
+// BudgetStats is cumulative usage since the judge was created, plus the current day.
+type BudgetStats struct {
+	Requests         int     // calls sent to the wrapped judge
+	Exhausted        int     // calls refused because the budget was spent
+	FallbackCalls    int     // calls sent to the fallback judge
+	PromptTokens     int     // tokens reported by the provider
+	CompletionTokens int     // tokens reported by the provider
+	Cost             float64 // in the currency of the token prices
+	DayTokens        int     // tokens spent today (UTC)
+	DayCost          float64 // cost spent today (UTC)
+}
+
+// BudgetJudge wraps an LLMJudge with a request rate limit, a cap on concurrent
+// calls and a daily token or cost budget. Spending is based on the Usage the
+// provider reports, so judges that report none only count requests. The budget
+// is checked before each call, so calls already in flight can overshoot it.
+type BudgetJudge struct {
+	judge    LLMJudge
+	fallback LLMJudge
+	policy   BudgetPolicy
+
+	dailyTokens int
+	dailyCost   float64
+	inputPrice  float64 // per million prompt tokens
+	outputPrice float64 // per million completion tokens
+	limiter     *rateLimiter
+	concurrency chan struct{}
+	now         func() time.Time
+	mu          sync.Mutex
+	day         time.Time
+	stats       BudgetStats
+}
+
+// BudgetOption configures a BudgetJudge.
+type BudgetOption func(*BudgetJudge)
+
+// WithRateLimit allows perSecond requests on average with bursts of up to burst.
+// Calls over the limit wait for a slot or until their context ends.
+func WithRateLimit(perSecond float64, burst int) BudgetOption {
+	return func(b *BudgetJudge) {
+		if perSecond > 0 {
+			if burst < 1 {
+				burst = 1
+			}
+			b.limiter = &rateLimiter{rate: perSecond, burst: float64(burst), tokens: float64(burst)}
+		}
+	}
+}
+
+// WithMaxConcurrent caps how many calls run at once. Extra calls wait for a slot.
+func WithMaxConcurrent(n int) BudgetOption {
+	return func(b *BudgetJudge) {
+		if n > 0 {
+			b.concurrency = make(chan struct{}, n)
+		}
+	}
+}
+
+// WithDailyTokenBudget caps the tokens spent per UTC day.
+func WithDailyTokenBudget(tokens int) BudgetOption {
+	return func(b *BudgetJudge) {
+		if tokens > 0 {
+			b.dailyTokens = tokens
+		}
+	}
+}
+
+// WithDailyCostBudget caps the cost spent per UTC day. Needs WithTokenPrices.
+func WithDailyCostBudget(cost float64) BudgetOption {
+	return func(b *BudgetJudge) {
+		if cost > 0 {
+			b.dailyCost = cost
+		}
+	}
+}
+
+// WithTokenPrices sets the price per million prompt and completion tokens,
+// as listed on the provider's pricing page.
+func WithTokenPrices(inputPerMillion, outputPerMillion float64) BudgetOption {
+	return func(b *BudgetJudge) {
+		if inputPerMillion >= 0 && outputPerMillion >= 0 {
+			b.inputPrice = inputPerMillion
+			b.outputPrice = outputPerMillion
+		}
+	}
+}
+
+// WithBudgetPolicy sets what happens once the budget is spent. Default is BudgetSkip.
+func WithBudgetPolicy(policy BudgetPolicy) BudgetOption {
+	return func(b *BudgetJudge) {
+		b.policy = policy
+	}
+}
+
+// WithBudgetFallback switches to BudgetFallback with the given (cheaper) judge.
+// The fallback is not rate limited or counted against the budget.
+func WithBudgetFallback(judge LLMJudge) BudgetOption {
+	return func(b *BudgetJudge) {
+		if judge != nil {
+			b.policy = BudgetFallback
+			b.fallback = judge
+		}
+	}
+}
+
+// NewBudgetJudge wraps judge with rate, concurrency and spending limits.
+//
+// Example:
+//
+//	judge := detector.NewBudgetJudge(
+//	    detector.NewOpenAIJudge(apiKey, "gpt-5"),
+//	    detector.WithRateLimit(5, 10),
+//	    detector.WithMaxConcurrent(4),
+//	    detector.WithTokenPrices(1.25, 10),
+//	    detector.WithDailyCostBudget(20),
+//	    detector.WithBudgetFallback(detector.NewOllamaJudge("llama3.1:8b")),
+//	)
+func NewBudgetJudge(judge LLMJudge, opts ...BudgetOption) *BudgetJudge {
+	b := &BudgetJudge{
+		judge: judge,
+		now:   time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(b)
+	}
+	if b.policy == BudgetFallback && b.fallback == nil {
+		b.policy = BudgetSkip
+	}
+	return b
+}
+
+// Judge calls the wrapped judge once a rate and concurrency slot is free,
+// or applies the budget policy if today's budget is spent. Usage is charged
+// even when the call fails, e.g. on an unparsable reply.
+func (b *BudgetJudge) Judge(ctx context.Context, input string) (LLMResult, error) {
+	if b.exhausted() {
+		return b.onExhausted(ctx, input)
+	}
+
+	if b.limiter != nil {
+		if err := b.limiter.wait(ctx); err != nil {
+			return LLMResult{}, err
+		}
+	}
+	if b.concurrency != nil {
+		select {
+		case b.concurrency <- struct{}{}:
+			defer func() { <-b.concurrency }()
+		case <-ctx.Done():
+			return LLMResult{}, ctx.Err()
+		}
+	}
+
+	b.mu.Lock()
+	b.stats.Requests++
+	b.mu.Unlock()
+
+	result, err := b.judge.Judge(ctx, input)
+	b.record(result.Usage)
+	return result, err
+}
+
+// JudgeBatch sends inputs to the wrapped judge in one JudgeBatch call, which
+// takes one rate and concurrency slot and counts as one request, when the judge
+// is a BatchJudge. Otherwise it calls Judge for each input. Once the budget is
+// spent, the budget policy applies to every input.
+func (b *BudgetJudge) JudgeBatch(ctx context.Context, inputs []string) ([]LLMResult, error) {
+	if _, ok := b.judge.(BatchJudge); !ok {
+		results := make([]LLMResult, len(inputs))
+		errs := make([]error, len(inputs))
+		for i, input := range inputs {
+			results[i], errs[i] = b.Judge(ctx, input)
+		}
+		return results, batchErrors(errs)
+	}
+
+	if b.exhausted() {
+		results := make([]LLMResult, len(inputs))
+		errs := make([]error, len(inputs))
+		for i, input := range inputs {
+			results[i], errs[i] = b.onExhausted(ctx, input)
+		}
+		return results, batchErrors(errs)
+	}
+
+	if b.limiter != nil {
+		if err := b.limiter.wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if b.concurrency != nil {
+		select {
+		case b.concurrency <- struct{}{}:
+			defer func() { <-b.concurrency }()
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	b.mu.Lock()
+	b.stats.Requests++
+	b.mu.Unlock()
+
+	results, errs := judgeEach(ctx, b.judge, inputs)
+	var usage TokenUsage
+	for _, r := range results {
+		usage = usage.add(r.Usage)
+	}
+	b.record(usage)
+	return results, batchErrors(errs)
+}
+
+func (b *BudgetJudge) batchLimit() int {
+	return batchLimit(b.judge)
+}
+
+func (b *BudgetJudge) wrapsBatchJudge() bool {
+	return batches(b.judge)
+}
+
+// callTimeout is the longer of the wrapped judge's and the fallback's
+// timeouts, as each call uses one of them.
+func (b *BudgetJudge) callTimeout() time.Duration {
+	d := judgeTimeout(b.judge)
+	if b.fallback != nil {
+		d = max(d, judgeTimeout(b.fallback))
+	}
+	return d
+}
+
+// CacheIdentity covers the wrapped judge, the budget policy and the fallback.
+func (b *BudgetJudge) CacheIdentity() string {
+	id := cacheIdentity(b.judge)
+	if id == "" {
+		return ""
+	}
+	if b.fallback == nil {
+		return joinIdentity(fmt.Sprintf("budget/%d", b.policy), id)
+	}
+	fallback := cacheIdentity(b.fallback)
+	if fallback == "" {
+		return ""
+	}
+	return joinIdentity(fmt.Sprintf("budget/%d", b.policy), id, fallback)
+}
+
+// Warmup warms the wrapped judge and the fallback.
+func (b *BudgetJudge) Warmup(ctx context.Context) {
+	b.judge.Warmup(ctx)
+	if b.fallback != nil {
+		b.fallback.Warmup(ctx)
+	}
+}
+
+// Stats returns cumulative and today's usage.
+func (b *BudgetJudge) Stats() BudgetStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.resetDay()
+	return b.stats
+}
+
+func (b *BudgetJudge) exhausted() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.resetDay()
+
+	spent := (b.dailyTokens > 0 && b.stats.DayTokens >= b.dailyTokens) ||
+		(b.dailyCost > 0 && b.stats.DayCost >= b.dailyCost)
+	if spent {
+		b.stats.Exhausted++
+	}
+	return spent
+}
+
+func (b *BudgetJudge) onExhausted(ctx context.Context, input string) (LLMResult, error) {
+	switch b.policy {
+	case BudgetFailClosed:
+		return LLMResult{}, errBudgetFailClosed
+	case BudgetFallback:
+		b.mu.Lock()
+		b.stats.FallbackCalls++
+		b.mu.Unlock()
+		return b.fallback.Judge(ctx, input)
+	default:
+		return LLMResult{}, ErrBudgetExhausted
+	}
+}
+
+func (b *BudgetJudge) record(usage TokenUsage) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.resetDay()
+
+	tokens := usage.TotalTokens
+	if tokens == 0 {
+		tokens = usage.PromptTokens + usage.CompletionTokens
+	}
+	cost := (float64(usage.PromptTokens)*b.inputPrice + float64(usage.CompletionTokens)*b.outputPrice) / 1e6
+
+	b.stats.PromptTokens += usage.PromptTokens
+	b.stats.CompletionTokens += usage.CompletionTokens
+	b.stats.Cost += cost
+	b.stats.DayTokens += tokens
+	b.stats.DayCost += cost
+}
+
+// resetDay clears today's spending when the UTC day changes. Caller holds b.mu.
+func (b *BudgetJudge) resetDay() {
+	today := b.now().UTC().Truncate(24 * time.Hour)
+	if !today.Equal(b.day) {
+		b.day = today
+		b.stats.DayTokens = 0
+		b.stats.DayCost = 0
+	}
+}
+
+// rateLimiter is a token bucket refilled at rate per second up to burst.
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// wait takes a token, sleeping until one is available or ctx ends.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		if !l.last.IsZero() {
+			l.tokens += now.Sub(l.last).Seconds() * l.rate
+			if l.tokens > l.burst {
+				l.tokens = l.burst
+			}
+		}
+		l.last = now
+		if l.tokens >= 1 {
+			l.tokens--
+			l.mu.Unlock()
+			return nil
+		}
+		delay := time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+		l.mu.Unlock()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
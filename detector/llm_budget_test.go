@@ -0,0 +1,210 @@
+package detector
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// usageJudge reports fixed token usage and err on every call.
+type usageJudge struct {
+	usage TokenUsage
+	err   error
+	calls atomic.Int32
+}
+
+func (j *usageJudge) Judge(ctx context.Context, input string) (LLMResult, error) {
+	j.calls.Add(1)
+	return LLMResult{Confidence: 0.9, Usage: j.usage}, j.err
+}
+
+func (j *usageJudge) Warmup(ctx context.Context) {}
+
+func TestBudgetJudge_TokenBudget(t *testing.T) {
+	inner := &usageJudge{usage: TokenUsage{PromptTokens: 80, CompletionTokens: 20, TotalTokens: 100}}
+	judge := NewBudgetJudge(inner, WithDailyTokenBudget(250))
+
+	for i := 0; i < 3; i++ {
+		_, err := judge.Judge(context.Background(), "input")
+		require.NoError(t, err)
+	}
+	_, err := judge.Judge(context.Background(), "input")
+	assert.ErrorIs(t, err, ErrBudgetExhausted)
+	assert.Equal(t, int32(3), inner.calls.Load())
+
+	stats := judge.Stats()
+	assert.Equal(t, 3, stats.Requests)
+	assert.Equal(t, 1, stats.Exhausted)
+	assert.Equal(t, 240, stats.PromptTokens)
+	assert.Equal(t, 60, stats.CompletionTokens)
+	assert.Equal(t, 300, stats.DayTokens)
+}
+
+func TestBudgetJudge_CostBudgetResetsDaily(t *testing.T) {
+	inner := &usageJudge{usage: TokenUsage{PromptTokens: 1_000_000, CompletionTokens: 100_000}}
+	now := time.Date(2025, 1, 1, 23, 0, 0, 0, time.UTC)
+	judge := NewBudgetJudge(inner, WithTokenPrices(1, 10), WithDailyCostBudget(2))
+	judge.now = func() time.Time { return now }
+
+	_, err := judge.Judge(context.Background(), "input")
+	require.NoError(t, err)
+	assert.InDelta(t, 2.0, judge.Stats().Cost, 1e-9, "1M input at $1 + 100k output at $10")
+
+	_, err = judge.Judge(context.Background(), "input")
+	assert.ErrorIs(t, err, ErrBudgetExhausted)
+
+	now = now.Add(2 * time.Hour)
+	_, err = judge.Judge(context.Background(), "input")
+	require.NoError(t, err)
+
+	stats := judge.Stats()
+	assert.InDelta(t, 4.0, stats.Cost, 1e-9)
+	assert.InDelta(t, 2.0, stats.DayCost, 1e-9)
+}
+
+func TestBudgetJudge_Policies(t *testing.T) {
+	spent := func(opts ...BudgetOption) *BudgetJudge {
+		inner := &usageJudge{usage: TokenUsage{TotalTokens: 10}}
+		judge := NewBudgetJudge(inner, append([]BudgetOption{WithDailyTokenBudget(10)}, opts...)...)
+		_, err := judge.Judge(context.Background(), "input")
+		require.NoError(t, err)
+		return judge
+	}
+
+	t.Run("fail closed", func(t *testing.T) {
+		_, err := spent(WithBudgetPolicy(BudgetFailClosed)).Judge(context.Background(), "input")
+		assert.ErrorIs(t, err, ErrBudgetExhausted)
+	})
+
+	t.Run("fail closed blocks under FailOpen", func(t *testing.T) {
+		guard := New(WithLLM(spent(WithBudgetPolicy(BudgetFailClosed)), LLMAlways), WithFailurePolicy(FailOpen))
+		result := guard.Detect(context.Background(), "What is the weather today?")
+		assert.False(t, result.Safe)
+		assert.True(t, result.Incomplete)
+		assert.Contains(t, result.DecisionPath, DecisionFailClosed)
+	})
+
+	t.Run("fail closed is not cached", func(t *testing.T) {
+		budget := spent(WithBudgetPolicy(BudgetFailClosed))
+		cache := NewCachingJudge(budget)
+		_, err := cache.Judge(context.Background(), "hello")
+		assert.ErrorIs(t, err, ErrBudgetExhausted)
+		assert.Zero(t, cache.Stats().Size)
+
+		budget.now = func() time.Time { return time.Now().Add(24 * time.Hour) } // the budget resets
+		result, err := cache.Judge(context.Background(), "hello")
+		require.NoError(t, err)
+		assert.False(t, result.IsAttack)
+	})
+
+	t.Run("fallback", func(t *testing.T) {
+		judge := spent(WithBudgetFallback(attackVote(0.7, "prompt_leak")))
+		result, err := judge.Judge(context.Background(), "input")
+		require.NoError(t, err)
+		assert.Equal(t, "prompt_leak", result.AttackType)
+		assert.Equal(t, 1, judge.Stats().FallbackCalls)
+	})
+
+	t.Run("fallback without judge skips", func(t *testing.T) {
+		_, err := spent(WithBudgetPolicy(BudgetFallback)).Judge(context.Background(), "input")
+		assert.ErrorIs(t, err, ErrBudgetExhausted)
+	})
+}
+
+func TestBudgetJudge_RateLimit(t *testing.T) {
+	judge := NewBudgetJudge(&usageJudge{}, WithRateLimit(20, 2))
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		_, err := judge.Judge(context.Background(), "input")
+		require.NoError(t, err)
+	}
+	// Burst of 2 is free, the next 2 wait ~50ms each
+	assert.GreaterOrEqual(t, time.Since(start), 80*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	_, err := judge.Judge(ctx, "input")
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Equal(t, 4, judge.Stats().Requests, "a call that never got a rate slot is not a request")
+}
+
+func TestBudgetJudge_ChargesFailedCalls(t *testing.T) {
+	inner := &usageJudge{usage: TokenUsage{PromptTokens: 90, CompletionTokens: 10, TotalTokens: 100}, err: errors.New("unparsable reply")}
+	judge := NewBudgetJudge(inner, WithDailyTokenBudget(150))
+
+	for i := 0; i < 2; i++ {
+		_, err := judge.Judge(context.Background(), "input")
+		require.Error(t, err)
+	}
+	_, err := judge.Judge(context.Background(), "input")
+	assert.ErrorIs(t, err, ErrBudgetExhausted)
+
+	stats := judge.Stats()
+	assert.Equal(t, 2, stats.Requests)
+	assert.Equal(t, 200, stats.DayTokens)
+}
+
+func TestBudgetJudge_MaxConcurrent(t *testing.T) {
+	var running, peak atomic.Int32
+	inner := &slowJudge{delay: 20 * time.Millisecond}
+	judge := NewBudgetJudge(judgeFunc(func(ctx context.Context, input string) (LLMResult, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		return inner.Judge(ctx, input)
+	}), WithMaxConcurrent(2))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			judge.Judge(context.Background(), "input")
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(2), peak.Load())
+}
+
+type judgeFunc func(ctx context.Context, input string) (LLMResult, error)
+
+func (f judgeFunc) Judge(ctx context.Context, input string) (LLMResult, error) { return f(ctx, input) }
+func (f judgeFunc) Warmup(ctx context.Context)                                 {}
+
+func TestGenericLLMJudge_ReportsUsage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(withRequestNonce(t, r, `{"choices":[{"message":{"content":"VERDICT-{nonce}: SAFE"}}],"usage":{"prompt_tokens":120,"completion_tokens":1,"total_tokens":121}}`)))
+	}))
+	defer server.Close()
+
+	judge := NewGenericLLMJudge(server.URL, "", "test-model")
+	result, err := judge.Judge(context.Background(), "hello")
+	require.NoError(t, err)
+	assert.Equal(t, TokenUsage{PromptTokens: 120, CompletionTokens: 1, TotalTokens: 121}, result.Usage)
+}
+
+func TestGenericLLMJudge_ReportsUsageOnParseError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"choices":[{"message":{"content":"I cannot help with that."}}],"usage":{"prompt_tokens":120,"completion_tokens":6,"total_tokens":126}}`))
+	}))
+	defer server.Close()
+
+	judge := NewGenericLLMJudge(server.URL, "", "test-model")
+	result, err := judge.Judge(context.Background(), "hello")
+	require.Error(t, err)
+	assert.Equal(t, TokenUsage{PromptTokens: 120, CompletionTokens: 6, TotalTokens: 126}, result.Usage)
+}
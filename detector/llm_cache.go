@@ -0,0 +1,427 @@
+package detector
+
+import (
+	"bufio"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CacheIdentifier is implemented by judges whose verdict depends on configuration
+// (model, system prompt, output format). CachingJudge mixes the identity into
+// every cache key so a persisted cache is never reused by a different setup.
+// Wrapper judges compose the identities of the judges they wrap, and report
+// none if any of them has none.
+type CacheIdentifier interface {
+	CacheIdentity() string
+}
+
+// cacheIdentity returns judge's CacheIdentity, or "" if it has none.
+func cacheIdentity(judge LLMJudge) string {
+	if id, ok := judge.(CacheIdentifier); ok {
+		return id.CacheIdentity()
+	}
+	return ""
+}
+
+// joinIdentity builds a wrapper's identity from its kind and parts,
+// length-prefixed so nested identities cannot run into each other.
+func joinIdentity(kind string, parts ...string) string {
+	var b strings.Builder
+	b.WriteString(kind)
+	for _, p := range parts {
+		fmt.Fprintf(&b, "\x00%d:%s", len(p), p)
+	}
+	return b.String()
+}
+
+// CacheStats counts cache outcomes since the judge was created.
+type CacheStats struct {
+	Hits   int // served from the cache
+	Misses int // sent to the wrapped judge
+	Shared int // waited for an identical in-flight call instead of sending another
+	Size   int // entries currently cached
+}
+
+// CachingJudge wraps any LLMJudge with an in-memory LRU cache, optional on-disk
+// persistence and collapsing of identical concurrent calls. Errors are never cached.
+type CachingJudge struct {
+	judge     LLMJudge
+	namespace string
+	capacity  int
+	ttl       time.Duration
+	path      string
+
+	mu       sync.Mutex
+	order    *list.List // front = most recently used
+	entries  map[string]*list.Element
+	inflight map[string]*cacheCall
+	stats    CacheStats
+	file     *os.File
+}
+
+type cacheEntry struct {
+	Key     string    `json:"key"`
+	Result  LLMResult `json:"result"`
+	Created time.Time `json:"created"`
+}
+
+type cacheCall struct {
+	done      chan struct{}
+	result    LLMResult
+	err       error
+	abandoned bool // the caller's context ended, so err says nothing about the input
+}
+
+// CacheOption configures a CachingJudge.
+type CacheOption func(*CachingJudge)
+
+// WithCacheSize sets how many verdicts are kept in memory. Default is 1000.
+func WithCacheSize(size int) CacheOption {
+	return func(c *CachingJudge) {
+		if size > 0 {
+			c.capacity = size
+		}
+	}
+}
+
+// WithCacheTTL expires cached verdicts after ttl. Default is 0 (never expire).
+func WithCacheTTL(ttl time.Duration) CacheOption {
+	return func(c *CachingJudge) {
+		if ttl >= 0 {
+			c.ttl = ttl
+		}
+	}
+}
+
+// WithCacheFile persists verdicts to a JSON lines file so they survive restarts.
+// Existing entries are loaded when the judge is created; new ones are appended.
+// Ignored unless the judge has a CacheIdentity or WithCacheNamespace is set,
+// since a file shared by unidentified judges would serve one's verdicts to another.
+func WithCacheFile(path string) CacheOption {
+	return func(c *CachingJudge) {
+		c.path = path
+	}
+}
+
+// WithCacheNamespace sets the identity mixed into cache keys. Needed for custom
+// judges that do not implement CacheIdentifier but share a cache file.
+func WithCacheNamespace(namespace string) CacheOption {
+	return func(c *CachingJudge) {
+		c.namespace = namespace
+	}
+}
+
+// NewCachingJudge wraps judge with a verdict cache.
+//
+// Example:
+//
+//	judge := detector.NewCachingJudge(
+//	    detector.NewOpenAIJudge(apiKey, "gpt-5"),
+//	    detector.WithCacheSize(10000),
+//	    detector.WithCacheFile("llm-cache.jsonl"),
+//	)
+//	defer judge.Close()
+func NewCachingJudge(judge LLMJudge, opts ...CacheOption) *CachingJudge {
+	c := &CachingJudge{
+		judge:    judge,
+		capacity: 1000,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+		inflight: make(map[string]*cacheCall),
+	}
+	c.namespace = cacheIdentity(judge)
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if c.path != "" && c.namespace != "" {
+		if lines := c.load(); lines > c.order.Len() {
+			c.compact()
+		}
+		// Persistence is best effort: without the file the cache still works in memory
+		if f, err := os.OpenFile(c.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644); err == nil {
+			c.file = f
+		}
+	}
+
+	return c
+}
+
+// Judge returns a cached verdict for the input if there is one, otherwise asks
+// the wrapped judge once even if several goroutines ask for the same input.
+// If the goroutine that asked gives up (its context ends), the others ask again.
+// Cached verdicts report zero Usage since no tokens were spent on them.
+func (c *CachingJudge) Judge(ctx context.Context, input string) (LLMResult, error) {
+	key := c.key(input)
+
+	c.mu.Lock()
+	if result, ok := c.get(key); ok {
+		c.stats.Hits++
+		c.mu.Unlock()
+		result.Usage = TokenUsage{}
+		return result, nil
+	}
+	if call, ok := c.inflight[key]; ok {
+		c.stats.Shared++
+		c.mu.Unlock()
+		select {
+		case <-call.done:
+			if call.abandoned {
+				return c.Judge(ctx, input)
+			}
+			result := call.result
+			result.Usage = TokenUsage{}
+			return result, call.err
+		case <-ctx.Done():
+			return LLMResult{}, ctx.Err()
+		}
+	}
+	call := &cacheCall{done: make(chan struct{})}
+	c.inflight[key] = call
+	c.stats.Misses++
+	c.mu.Unlock()
+
+	call.result, call.err = c.judge.Judge(ctx, input)
+
+	c.mu.Lock()
+	delete(c.inflight, key)
+	call.abandoned = call.err != nil && ctx.Err() != nil
+	if call.err == nil {
+		entry := cacheEntry{Key: key, Result: call.result, Created: time.Now()}
+		c.put(entry)
+		c.persist(entry)
+	}
+	c.mu.Unlock()
+	close(call.done)
+
+	return call.result, call.err
+}
+
+// JudgeBatch answers cached inputs from the cache and sends the rest to the
+// wrapped judge in one JudgeBatch call if it is a BatchJudge, otherwise one by one.
+// Inputs already in flight, in this batch or elsewhere, are sent only once.
+func (c *CachingJudge) JudgeBatch(ctx context.Context, inputs []string) ([]LLMResult, error) {
+	results := make([]LLMResult, len(inputs))
+	errs := make([]error, len(inputs))
+	keys := make([]string, len(inputs))
+	waiting := make(map[int]*cacheCall)
+	var missed []int
+
+	c.mu.Lock()
+	for i, input := range inputs {
+		keys[i] = c.key(input)
+		if result, ok := c.get(keys[i]); ok {
+			c.stats.Hits++
+			result.Usage = TokenUsage{}
+			results[i] = result
+			continue
+		}
+		if call, ok := c.inflight[keys[i]]; ok {
+			c.stats.Shared++
+			waiting[i] = call
+			continue
+		}
+		c.inflight[keys[i]] = &cacheCall{done: make(chan struct{})}
+		c.stats.Misses++
+		missed = append(missed, i)
+	}
+	c.mu.Unlock()
+
+	texts := make([]string, len(missed))
+	for k, i := range missed {
+		texts[k] = inputs[i]
+	}
+	missResults, missErrs := judgeEach(ctx, c.judge, texts)
+
+	c.mu.Lock()
+	var calls []*cacheCall
+	for k, i := range missed {
+		call := c.inflight[keys[i]]
+		delete(c.inflight, keys[i])
+		call.result, call.err = missResults[k], missErrs[k]
+		call.abandoned = call.err != nil && ctx.Err() != nil
+		results[i], errs[i] = call.result, call.err
+		if call.err == nil {
+			entry := cacheEntry{Key: keys[i], Result: call.result, Created: time.Now()}
+			c.put(entry)
+			c.persist(entry)
+		}
+		calls = append(calls, call)
+	}
+	c.mu.Unlock()
+	for _, call := range calls {
+		close(call.done)
+	}
+
+	for i, call := range waiting {
+		select {
+		case <-call.done:
+			if call.abandoned {
+				results[i], errs[i] = c.Judge(ctx, inputs[i])
+				continue
+			}
+			results[i], errs[i] = call.result, call.err
+			results[i].Usage = TokenUsage{}
+		case <-ctx.Done():
+			errs[i] = ctx.Err()
+		}
+	}
+	return results, batchErrors(errs)
+}
+
+func (c *CachingJudge) batchLimit() int {
+	return batchLimit(c.judge)
+}
+
+func (c *CachingJudge) wrapsBatchJudge() bool {
+	return batches(c.judge)
+}
+
+// callTimeout is the wrapped judge's timeout.
+func (c *CachingJudge) callTimeout() time.Duration {
+	return judgeTimeout(c.judge)
+}
+
+// CacheIdentity is the namespace of the cache, so caches can be nested.
+func (c *CachingJudge) CacheIdentity() string {
+	return c.namespace
+}
+
+// Warmup warms the wrapped judge.
+func (c *CachingJudge) Warmup(ctx context.Context) {
+	c.judge.Warmup(ctx)
+}
+
+// Stats returns hit, miss and collapse counts.
+func (c *CachingJudge) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Size = c.order.Len()
+	return stats
+}
+
+// Close closes the cache file, if any.
+func (c *CachingJudge) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.file == nil {
+		return nil
+	}
+	err := c.file.Close()
+	c.file = nil
+	return err
+}
+
+func (c *CachingJudge) key(input string) string {
+	h := sha256.New()
+	h.Write([]byte(c.namespace))
+	h.Write([]byte{0})
+	h.Write([]byte(input))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// get returns a live entry and marks it recently used. Caller holds c.mu.
+func (c *CachingJudge) get(key string) (LLMResult, bool) {
+	elem, ok := c.entries[key]
+	if !ok {
+		return LLMResult{}, false
+	}
+	entry := elem.Value.(cacheEntry)
+	if c.expired(entry) {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+		return LLMResult{}, false
+	}
+	c.order.MoveToFront(elem)
+	return entry.Result, true
+}
+
+// put stores an entry and evicts the least recently used beyond capacity. Caller holds c.mu.
+func (c *CachingJudge) put(entry cacheEntry) {
+	if elem, ok := c.entries[entry.Key]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[entry.Key] = c.order.PushFront(entry)
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(cacheEntry).Key)
+	}
+}
+
+func (c *CachingJudge) expired(entry cacheEntry) bool {
+	return c.ttl > 0 && time.Since(entry.Created) > c.ttl
+}
+
+// persist appends an entry to the cache file. Caller holds c.mu.
+func (c *CachingJudge) persist(entry cacheEntry) {
+	if c.file == nil {
+		return
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	c.file.Write(append(data, '\n'))
+}
+
+// load reads the cache file in order, so later lines win and the newest
+// entries survive eviction. Unreadable lines are skipped. Returns the line count.
+func (c *CachingJudge) load() int {
+	f, err := os.Open(c.path)
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+
+	lines := 0
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		lines++
+		var entry cacheEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil || entry.Key == "" || c.expired(entry) {
+			continue
+		}
+		c.put(entry)
+	}
+	return lines
+}
+
+// compact rewrites the cache file with only the loaded entries, dropping
+// evicted, expired and overwritten lines so the file does not grow forever.
+func (c *CachingJudge) compact() {
+	tmp := c.path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return
+	}
+
+	w := bufio.NewWriter(f)
+	for elem := c.order.Back(); elem != nil; elem = elem.Prev() {
+		data, err := json.Marshal(elem.Value.(cacheEntry))
+		if err != nil {
+			continue
+		}
+		w.Write(append(data, '\n'))
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return
+	}
+	f.Close()
+	os.Rename(tmp, c.path)
+}
@@ -0,0 +1,251 @@
+package detector
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingJudge flags inputs containing "ignore" and counts calls.
+// If gate is set, every call blocks until it is closed.
+type countingJudge struct {
+	calls atomic.Int32
+	gate  chan struct{}
+	err   error
+}
+
+func (j *countingJudge) Judge(ctx context.Context, input string) (LLMResult, error) {
+	j.calls.Add(1)
+	if j.gate != nil {
+		<-j.gate
+	}
+	if j.err != nil {
+		return LLMResult{}, j.err
+	}
+	return LLMResult{IsAttack: input == "ignore", Confidence: 0.9}, nil
+}
+
+func (j *countingJudge) Warmup(ctx context.Context) {}
+
+func TestCachingJudge_Hits(t *testing.T) {
+	inner := &countingJudge{}
+	judge := NewCachingJudge(inner)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		result, err := judge.Judge(ctx, "ignore")
+		require.NoError(t, err)
+		assert.True(t, result.IsAttack)
+	}
+	_, err := judge.Judge(ctx, "hello")
+	require.NoError(t, err)
+
+	assert.Equal(t, int32(2), inner.calls.Load())
+	stats := judge.Stats()
+	assert.Equal(t, 2, stats.Hits)
+	assert.Equal(t, 2, stats.Misses)
+	assert.Equal(t, 2, stats.Size)
+}
+
+func TestCachingJudge_LRUEviction(t *testing.T) {
+	inner := &countingJudge{}
+	judge := NewCachingJudge(inner, WithCacheSize(2))
+	ctx := context.Background()
+
+	judge.Judge(ctx, "a")
+	judge.Judge(ctx, "b")
+	judge.Judge(ctx, "a") // a is now most recently used
+	judge.Judge(ctx, "c") // evicts b
+
+	judge.Judge(ctx, "a")
+	assert.Equal(t, int32(3), inner.calls.Load(), "a stays cached")
+	judge.Judge(ctx, "b")
+	assert.Equal(t, int32(4), inner.calls.Load(), "b was evicted")
+}
+
+func TestCachingJudge_TTL(t *testing.T) {
+	inner := &countingJudge{}
+	judge := NewCachingJudge(inner, WithCacheTTL(20*time.Millisecond))
+	ctx := context.Background()
+
+	judge.Judge(ctx, "a")
+	judge.Judge(ctx, "a")
+	time.Sleep(30 * time.Millisecond)
+	judge.Judge(ctx, "a")
+	assert.Equal(t, int32(2), inner.calls.Load())
+}
+
+func TestCachingJudge_ErrorsNotCached(t *testing.T) {
+	inner := &countingJudge{err: errors.New("rate limited")}
+	judge := NewCachingJudge(inner)
+	ctx := context.Background()
+
+	_, err := judge.Judge(ctx, "a")
+	assert.Error(t, err)
+	_, err = judge.Judge(ctx, "a")
+	assert.Error(t, err)
+	assert.Equal(t, int32(2), inner.calls.Load())
+}
+
+func TestCachingJudge_CollapsesInFlight(t *testing.T) {
+	inner := &countingJudge{gate: make(chan struct{})}
+	judge := NewCachingJudge(inner)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	results := make([]LLMResult, 10)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = judge.Judge(ctx, "ignore")
+		}(i)
+	}
+
+	// Let every goroutine reach the cache before the single upstream call returns
+	require.Eventually(t, func() bool {
+		s := judge.Stats()
+		return s.Misses+s.Shared == len(results)
+	}, time.Second, time.Millisecond)
+	close(inner.gate)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), inner.calls.Load())
+	for _, r := range results {
+		assert.True(t, r.IsAttack)
+	}
+}
+
+// abandonJudge blocks the first call until its context ends and answers the rest.
+type abandonJudge struct {
+	calls   atomic.Int32
+	started chan struct{}
+}
+
+func (j *abandonJudge) Judge(ctx context.Context, input string) (LLMResult, error) {
+	if j.calls.Add(1) == 1 {
+		close(j.started)
+		<-ctx.Done()
+		return LLMResult{}, ctx.Err()
+	}
+	return LLMResult{IsAttack: true, Confidence: 0.9}, nil
+}
+
+func (j *abandonJudge) Warmup(ctx context.Context) {}
+
+func TestCachingJudge_RetriesAbandonedCall(t *testing.T) {
+	inner := &abandonJudge{started: make(chan struct{})}
+	judge := NewCachingJudge(inner)
+
+	leaderCtx, cancel := context.WithCancel(context.Background())
+	leaderErr := make(chan error, 1)
+	go func() {
+		_, err := judge.Judge(leaderCtx, "ignore")
+		leaderErr <- err
+	}()
+	<-inner.started
+
+	followerDone := make(chan struct{})
+	var result LLMResult
+	var err error
+	go func() {
+		defer close(followerDone)
+		result, err = judge.Judge(context.Background(), "ignore")
+	}()
+	require.Eventually(t, func() bool { return judge.Stats().Shared == 1 }, time.Second, time.Millisecond)
+	cancel()
+
+	assert.ErrorIs(t, <-leaderErr, context.Canceled)
+	<-followerDone
+	require.NoError(t, err, "the follower does not inherit the leader's cancellation")
+	assert.True(t, result.IsAttack)
+	assert.Equal(t, int32(2), inner.calls.Load())
+}
+
+func TestCachingJudge_Persistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.jsonl")
+	ctx := context.Background()
+
+	first := NewCachingJudge(&countingJudge{}, WithCacheFile(path), WithCacheNamespace("model-a"))
+	_, err := first.Judge(ctx, "ignore")
+	require.NoError(t, err)
+	require.NoError(t, first.Close())
+
+	inner := &countingJudge{}
+	second := NewCachingJudge(inner, WithCacheFile(path), WithCacheNamespace("model-a"))
+	defer second.Close()
+	result, err := second.Judge(ctx, "ignore")
+	require.NoError(t, err)
+	assert.True(t, result.IsAttack)
+	assert.Equal(t, int32(0), inner.calls.Load(), "served from disk")
+
+	other := &countingJudge{}
+	third := NewCachingJudge(other, WithCacheFile(path), WithCacheNamespace("model-b"))
+	defer third.Close()
+	third.Judge(ctx, "ignore")
+	assert.Equal(t, int32(1), other.calls.Load(), "different namespace does not share entries")
+}
+
+func TestCachingJudge_KeyIncludesJudgeIdentity(t *testing.T) {
+	simple := NewCachingJudge(NewGenericLLMJudge("http://localhost", "", "model"))
+	structured := NewCachingJudge(NewGenericLLMJudge("http://localhost", "", "model", WithOutputFormat(LLMStructured)))
+	otherModel := NewCachingJudge(NewGenericLLMJudge("http://localhost", "", "model-2"))
+
+	assert.NotEqual(t, simple.key("x"), structured.key("x"))
+	assert.NotEqual(t, simple.key("x"), otherModel.key("x"))
+
+	schema := NewCachingJudge(NewGenericLLMJudge("http://localhost", "", "model", WithOutputFormat(LLMStructured), WithJSONSchema(true)))
+	assert.NotEqual(t, structured.key("x"), schema.key("x"))
+	assert.Equal(t, simple.key("x"), NewCachingJudge(NewGenericLLMJudge("http://localhost", "", "model")).key("x"))
+}
+
+func TestCachingJudge_WrapperIdentity(t *testing.T) {
+	a := NewGenericLLMJudge("http://localhost", "", "model")
+	b := NewGenericLLMJudge("http://localhost", "", "model", WithSystemPrompt("Answer SAFE or ATTACK."))
+
+	pairs := []struct {
+		name string
+		a, b LLMJudge
+	}{
+		{"failover", NewFailoverJudge(a, a), NewFailoverJudge(a, b)},
+		{"budget", NewBudgetJudge(a), NewBudgetJudge(b)},
+		{"budget fallback", NewBudgetJudge(a, WithBudgetFallback(a)), NewBudgetJudge(a, WithBudgetFallback(b))},
+		{"ensemble", NewEnsembleJudge([]EnsembleMember{{Judge: a}, {Judge: b}}), NewEnsembleJudge([]EnsembleMember{{Judge: a}, {Judge: b, Weight: 2}})},
+		{"ensemble rule", NewEnsembleJudge([]EnsembleMember{{Judge: a}}), NewEnsembleJudge([]EnsembleMember{{Judge: a}}, WithEnsembleRule(EnsembleUnanimous))},
+		{"cascade", NewCascadeJudge(CascadeStage{Judge: a, MinConfidence: 0.8}, CascadeStage{Judge: b}), NewCascadeJudge(CascadeStage{Judge: a, MinConfidence: 0.9}, CascadeStage{Judge: b})},
+		{"nested", NewCachingJudge(NewBudgetJudge(a)), NewCachingJudge(NewBudgetJudge(b))},
+	}
+	for _, p := range pairs {
+		t.Run(p.name, func(t *testing.T) {
+			idA, idB := cacheIdentity(p.a), cacheIdentity(p.b)
+			assert.NotEmpty(t, idA)
+			assert.NotEqual(t, idA, idB)
+			assert.NotEqual(t, NewCachingJudge(p.a).key("x"), NewCachingJudge(p.b).key("x"))
+		})
+	}
+
+	assert.Empty(t, cacheIdentity(NewFailoverJudge(a, &countingJudge{})), "an unidentified judge leaves the wrapper unidentified")
+}
+
+func TestCachingJudge_NoPersistenceWithoutIdentity(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.jsonl")
+
+	judge := NewCachingJudge(NewFailoverJudge(&countingJudge{}), WithCacheFile(path))
+	defer judge.Close()
+	_, err := judge.Judge(context.Background(), "ignore")
+	require.NoError(t, err)
+	assert.NoFileExists(t, path)
+
+	named := NewCachingJudge(NewFailoverJudge(&countingJudge{}), WithCacheFile(path), WithCacheNamespace("custom"))
+	defer named.Close()
+	_, err = named.Judge(context.Background(), "ignore")
+	require.NoError(t, err)
+	assert.FileExists(t, path)
+}
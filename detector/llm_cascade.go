@@ -0,0 +1,149 @@
+package detector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// CascadeStage is one step of a CascadeJudge.
+type CascadeStage struct {
+	Judge LLMJudge
+
+	// Name is recorded in LLMResult.DecidedBy. Default: "stage N".
+	Name string
+
+	// MinConfidence is the confidence this stage needs to decide on its own.
+	// Below it the input escalates to the next stage. Ignored for the last stage.
+	MinConfidence float64
+}
+
+// CascadeJudge runs stages from cheapest to most expensive and stops at the
+// first one that is confident enough and agrees with the pattern detectors.
+type CascadeJudge struct {
+	stages []CascadeStage
+}
+
+// NewCascadeJudge creates a judge that escalates through stages in order.
+// A stage escalates when its confidence is below its MinConfidence, when its
+// verdict disagrees with the pattern-based verdict (available when called by
+// MultiDetector, see PatternVerdictFromContext), or when it fails.
+//
+// Example:
+//
+//	judge := detector.NewCascadeJudge(
+//	    detector.CascadeStage{Name: "local", Judge: detector.NewOllamaJudge("llama3.1:8b"), MinConfidence: 0.85},
+//	    detector.CascadeStage{Name: "frontier", Judge: detector.NewOpenAIJudge(apiKey, "gpt-5")},
+//	)
+func NewCascadeJudge(stages ...CascadeStage) *CascadeJudge {
+	c := &CascadeJudge{stages: make([]CascadeStage, len(stages))}
+	for i, s := range stages {
+		if s.Name == "" {
+			s.Name = fmt.Sprintf("stage %d", i+1)
+		}
+		c.stages[i] = s
+	}
+	return c
+}
+
+// callTimeout is the sum of the stages' timeouts.
+func (c *CascadeJudge) callTimeout() time.Duration {
+	var total time.Duration
+	for _, stage := range c.stages {
+		total += judgeTimeout(stage.Judge)
+	}
+	return total
+}
+
+// CacheIdentity lists each stage's name, confidence threshold and judge identity in order.
+func (c *CascadeJudge) CacheIdentity() string {
+	parts := make([]string, 0, len(c.stages))
+	for _, stage := range c.stages {
+		id := cacheIdentity(stage.Judge)
+		if id == "" {
+			return ""
+		}
+		parts = append(parts, joinIdentity(fmt.Sprintf("%s/%g", stage.Name, stage.MinConfidence), id))
+	}
+	return joinIdentity("cascade", parts...)
+}
+
+// Judge returns the verdict of the first stage allowed to decide, with DecidedBy
+// set to its name, Reasoning prefixed by why earlier stages escalated and Usage
+// summed over every stage that ran.
+// If later stages fail, the last successful answer is used.
+func (c *CascadeJudge) Judge(ctx context.Context, input string) (LLMResult, error) {
+	if len(c.stages) == 0 {
+		return LLMResult{}, errors.New("no LLM judges configured")
+	}
+
+	patterns, hasPatterns := PatternVerdictFromContext(ctx)
+
+	var trail []string
+	var errs []error
+	var last *LLMResult
+	var usage TokenUsage
+	for i, stage := range c.stages {
+		result, err := stage.Judge.Judge(ctx, input)
+		usage = usage.add(result.Usage)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", stage.Name, err))
+			trail = append(trail, fmt.Sprintf("%s failed", stage.Name))
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+		result.DecidedBy = stage.Name
+		last = &result
+
+		if i == len(c.stages)-1 {
+			break
+		}
+		if result.Confidence < stage.MinConfidence {
+			trail = append(trail, fmt.Sprintf("%s unsure (%.2f < %.2f)", stage.Name, result.Confidence, stage.MinConfidence))
+			continue
+		}
+		if hasPatterns && result.IsAttack != patterns.Unsafe {
+			trail = append(trail, fmt.Sprintf("%s disagreed with patterns (score %.2f)", stage.Name, patterns.RiskScore))
+			continue
+		}
+		break
+	}
+
+	if last == nil {
+		return LLMResult{Usage: usage}, errors.Join(errs...)
+	}
+
+	// Only the escalations before the deciding stage explain the path
+	if n := c.stageIndex(last.DecidedBy); n < len(trail) {
+		trail = trail[:n]
+	}
+	if len(trail) > 0 {
+		reasoning := "escalated: " + strings.Join(trail, ", ")
+		if last.Reasoning != "" {
+			reasoning += "; " + last.Reasoning
+		}
+		last.Reasoning = reasoning
+	}
+	last.Usage = usage
+	return *last, nil
+}
+
+func (c *CascadeJudge) stageIndex(name string) int {
+	for i, s := range c.stages {
+		if s.Name == name {
+			return i
+		}
+	}
+	return len(c.stages)
+}
+
+// Warmup warms every stage so an escalation does not hit a cold model.
+func (c *CascadeJudge) Warmup(ctx context.Context) {
+	for _, s := range c.stages {
+		s.Judge.Warmup(ctx)
+	}
+}
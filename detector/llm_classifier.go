@@ -0,0 +1,461 @@
+package detector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ClassifierFormat is the wire format of a text-classification endpoint.
+type ClassifierFormat int
+
+const (
+	// ClassifierHF is the Hugging Face text-classification format served by
+	// TEI's /predict, Inference Endpoints and most "predict"-style wrappers:
+	// {"inputs": [...]} answered with label/score lists per input.
+	ClassifierHF ClassifierFormat = iota
+
+	// ClassifierKServeV2 is the KServe v2 (Open Inference Protocol) format:
+	// POST .../v2/models/<model>/infer with a BYTES input tensor, answered with
+	// a [batch, labels] score tensor (see WithLabelOrder) or a BYTES label tensor.
+	ClassifierKServeV2
+)
+
+// defaultAttackLabels cover Prompt Guard (INJECTION, JAILBREAK; LABEL_1 in v2)
+// and the common DeBERTa prompt-injection fine-tunes.
+var defaultAttackLabels = []string{"INJECTION", "JAILBREAK", "LABEL_1", "MALICIOUS", "UNSAFE", "ATTACK"}
+
+// ClassifierJudge implements LLMJudge and BatchJudge over a dedicated
+// prompt-injection classifier served behind a text-classification API.
+// The attack probability is the summed score of the attack labels.
+type ClassifierJudge struct {
+	endpoint     string
+	format       ClassifierFormat
+	apiKey       string
+	attackLabels map[string]bool
+	labelOrder   []string
+	threshold    float64
+	batchSize    int
+	maxChars     int
+	inputName    string
+	timeout      time.Duration
+	httpClient   *http.Client      // built from baseClient, transport and timeout after the options
+	baseClient   *http.Client      // WithClassifierHTTPClient
+	transport    http.RoundTripper // WithClassifierTransport
+	headers      map[string]string
+	hooks        []func(*http.Request) error
+}
+
+// ClassifierJudgeOption configures a ClassifierJudge.
+type ClassifierJudgeOption func(*ClassifierJudge)
+
+// WithClassifierFormat sets the endpoint's wire format. Default is ClassifierHF.
+func WithClassifierFormat(format ClassifierFormat) ClassifierJudgeOption {
+	return func(c *ClassifierJudge) {
+		c.format = format
+	}
+}
+
+// WithAttackLabels sets which labels mean attack (case-insensitive). Every other
+// label counts as benign. Default: INJECTION, JAILBREAK, LABEL_1, MALICIOUS, UNSAFE, ATTACK.
+func WithAttackLabels(labels ...string) ClassifierJudgeOption {
+	return func(c *ClassifierJudge) {
+		if len(labels) > 0 {
+			c.attackLabels = labelSet(labels)
+		}
+	}
+}
+
+// WithLabelOrder names the columns of a KServe v2 score tensor, e.g. the
+// model's id2label: "BENIGN", "INJECTION", "JAILBREAK". Required for score tensors.
+func WithLabelOrder(labels ...string) ClassifierJudgeOption {
+	return func(c *ClassifierJudge) {
+		c.labelOrder = labels
+	}
+}
+
+// WithAttackThreshold sets the attack probability at which an input is an attack. Default is 0.5.
+func WithAttackThreshold(threshold float64) ClassifierJudgeOption {
+	return func(c *ClassifierJudge) {
+		if threshold > 0 && threshold <= 1 {
+			c.threshold = threshold
+		}
+	}
+}
+
+// WithClassifierBatchSize sets how many inputs JudgeBatch sends per request. Default is 32.
+func WithClassifierBatchSize(n int) ClassifierJudgeOption {
+	return func(c *ClassifierJudge) {
+		if n > 0 {
+			c.batchSize = n
+		}
+	}
+}
+
+// WithMaxInputChars truncates longer inputs before sending them, keeping the
+// start and the end, where appended injections usually sit. Set it to roughly
+// the model's max length (512 tokens for Prompt Guard is about 2000 characters).
+// ClassifierHF also asks the server to truncate to the model's token limit.
+func WithMaxInputChars(n int) ClassifierJudgeOption {
+	return func(c *ClassifierJudge) {
+		if n > 0 {
+			c.maxChars = n
+		}
+	}
+}
+
+// WithKServeInputName sets the name of the KServe v2 input tensor. Default is "text".
+func WithKServeInputName(name string) ClassifierJudgeOption {
+	return func(c *ClassifierJudge) {
+		if name != "" {
+			c.inputName = name
+		}
+	}
+}
+
+// WithClassifierAPIKey sends the key as a bearer token (Hugging Face Inference Endpoints).
+func WithClassifierAPIKey(key string) ClassifierJudgeOption {
+	return func(c *ClassifierJudge) {
+		c.apiKey = key
+	}
+}
+
+// WithClassifierTimeout sets the timeout per request. Default is 10s.
+func WithClassifierTimeout(timeout time.Duration) ClassifierJudgeOption {
+	return func(c *ClassifierJudge) {
+		c.timeout = timeout
+	}
+}
+
+// WithClassifierHTTPClient is WithHTTPClient for a ClassifierJudge.
+func WithClassifierHTTPClient(client *http.Client) ClassifierJudgeOption {
+	return func(c *ClassifierJudge) {
+		c.baseClient = client
+	}
+}
+
+// WithClassifierTransport is WithTransport for a ClassifierJudge.
+func WithClassifierTransport(rt http.RoundTripper) ClassifierJudgeOption {
+	return func(c *ClassifierJudge) {
+		c.transport = rt
+	}
+}
+
+// WithClassifierHeaders is WithHeaders for a ClassifierJudge.
+func WithClassifierHeaders(headers map[string]string) ClassifierJudgeOption {
+	return func(c *ClassifierJudge) {
+		if c.headers == nil {
+			c.headers = make(map[string]string, len(headers))
+		}
+		for k, v := range headers {
+			c.headers[k] = v
+		}
+	}
+}
+
+// WithClassifierRequestHook is WithRequestHook for a ClassifierJudge.
+func WithClassifierRequestHook(hook func(*http.Request) error) ClassifierJudgeOption {
+	return func(c *ClassifierJudge) {
+		if hook != nil {
+			c.hooks = append(c.hooks, hook)
+		}
+	}
+}
+
+// NewClassifierJudge creates a judge for the text-classification endpoint at
+// endpoint (the full URL, e.g. "http://localhost:8080/predict").
+//
+// Example:
+//
+//	// text-embeddings-inference serving meta-llama/Prompt-Guard-86M
+//	judge := detector.NewClassifierJudge("http://localhost:8080/predict",
+//	    detector.WithMaxInputChars(2000),
+//	)
+//
+//	// KServe v2
+//	judge := detector.NewClassifierJudge("http://kserve/v2/models/prompt-guard/infer",
+//	    detector.WithClassifierFormat(detector.ClassifierKServeV2),
+//	    detector.WithLabelOrder("BENIGN", "INJECTION", "JAILBREAK"),
+//	)
+//	guard := detector.New(detector.WithLLM(judge, detector.LLMAlways))
+func NewClassifierJudge(endpoint string, opts ...ClassifierJudgeOption) *ClassifierJudge {
+	c := &ClassifierJudge{
+		endpoint:     endpoint,
+		attackLabels: labelSet(defaultAttackLabels),
+		threshold:    0.5,
+		batchSize:    32,
+		inputName:    "text",
+		timeout:      10 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.httpClient = newHTTPClient(c.baseClient, c.transport, c.timeout)
+	return c
+}
+
+// Judge classifies one input.
+func (c *ClassifierJudge) Judge(ctx context.Context, input string) (LLMResult, error) {
+	results, err := c.classify(ctx, []string{input})
+	if err != nil {
+		return LLMResult{}, err
+	}
+	return results[0], nil
+}
+
+// JudgeBatch classifies inputs in requests of up to the batch size
+// (see WithClassifierBatchSize). A failed request fails the inputs it carried.
+func (c *ClassifierJudge) JudgeBatch(ctx context.Context, inputs []string) ([]LLMResult, error) {
+	results := make([]LLMResult, len(inputs))
+	errs := make([]error, len(inputs))
+	failed := false
+
+	for start := 0; start < len(inputs); start += c.batchSize {
+		end := start + c.batchSize
+		if end > len(inputs) {
+			end = len(inputs)
+		}
+		chunk, err := c.classify(ctx, inputs[start:end])
+		if err != nil {
+			failed = true
+			for i := start; i < end; i++ {
+				errs[i] = err
+			}
+			continue
+		}
+		copy(results[start:end], chunk)
+	}
+
+	if failed {
+		return results, &BatchError{Errors: errs}
+	}
+	return results, nil
+}
+
+func (c *ClassifierJudge) batchLimit() int {
+	return c.batchSize
+}
+
+// Warmup is a no-op: classifier servers load their model at startup.
+func (c *ClassifierJudge) Warmup(ctx context.Context) {}
+
+// GetTimeout returns the configured timeout.
+func (c *ClassifierJudge) GetTimeout() time.Duration {
+	return c.timeout
+}
+
+// CacheIdentity identifies the endpoint and the label mapping.
+func (c *ClassifierJudge) CacheIdentity() string {
+	labels := make([]string, 0, len(c.attackLabels))
+	for l := range c.attackLabels {
+		labels = append(labels, l)
+	}
+	sort.Strings(labels)
+	return fmt.Sprintf("%s\x00%d\x00%s\x00%s\x00%g\x00%d",
+		c.endpoint, c.format, strings.Join(labels, ","), strings.Join(c.labelOrder, ","), c.threshold, c.maxChars)
+}
+
+// labelScore is one label of a classification.
+type labelScore struct {
+	Label string  `json:"label"`
+	Score float64 `json:"score"`
+}
+
+// classify sends one request for inputs and returns a result per input.
+func (c *ClassifierJudge) classify(ctx context.Context, inputs []string) ([]LLMResult, error) {
+	texts := make([]string, len(inputs))
+	for i, input := range inputs {
+		texts[i] = truncateMiddle(input, c.maxChars)
+	}
+
+	var payload any
+	if c.format == ClassifierKServeV2 {
+		payload = map[string]any{
+			"inputs": []map[string]any{{
+				"name":     c.inputName,
+				"shape":    []int{len(texts)},
+				"datatype": "BYTES",
+				"data":     texts,
+			}},
+		}
+	} else {
+		payload = map[string]any{"inputs": texts, "truncate": true}
+	}
+
+	req, err := newJSONRequest(ctx, c.endpoint, payload)
+	if err != nil {
+		return nil, err
+	}
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := doRequest(c.httpClient, c.headers, c.hooks, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	var scores [][]labelScore
+	if c.format == ClassifierKServeV2 {
+		scores, err = c.parseKServe(body, len(texts))
+	} else {
+		scores, err = parseHFScores(body, len(texts))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]LLMResult, len(scores))
+	for i, s := range scores {
+		results[i] = c.verdict(s)
+	}
+	return results, nil
+}
+
+// verdict turns one input's label scores into a result. When the server only
+// returned benign labels (top-k), the missing mass is taken as attack probability.
+func (c *ClassifierJudge) verdict(scores []labelScore) LLMResult {
+	var attack, benign float64
+	var top labelScore
+	sawAttack := false
+	for _, s := range scores {
+		if s.Score > top.Score {
+			top = s
+		}
+		if c.attackLabels[strings.ToUpper(s.Label)] {
+			attack += s.Score
+			sawAttack = true
+		} else {
+			benign += s.Score
+		}
+	}
+	if !sawAttack {
+		attack = 1 - benign
+	}
+	if attack < 0 {
+		attack = 0
+	} else if attack > 1 {
+		attack = 1
+	}
+
+	result := LLMResult{
+		IsAttack:   attack >= c.threshold,
+		Confidence: round(1-attack, 4),
+		Reasoning:  fmt.Sprintf("classifier: %s %.2f", top.Label, top.Score),
+	}
+	if result.IsAttack {
+		result.Confidence = round(attack, 4)
+	}
+	return result
+}
+
+// parseHFScores reads [[{label, score}, ...], ...], or a flat list for a single input.
+func parseHFScores(body []byte, n int) ([][]labelScore, error) {
+	var nested [][]labelScore
+	if err := json.Unmarshal(body, &nested); err == nil && len(nested) == n {
+		return nested, nil
+	}
+	var flat []labelScore
+	if err := json.Unmarshal(body, &flat); err == nil && n == 1 && len(flat) > 0 {
+		return [][]labelScore{flat}, nil
+	}
+
+	var apiErr struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(body, &apiErr) == nil && apiErr.Error != "" {
+		return nil, fmt.Errorf("classifier error: %s", apiErr.Error)
+	}
+	return nil, fmt.Errorf("unexpected classifier response for %d inputs: %.200s", n, body)
+}
+
+// parseKServe reads the first output tensor: FP32 scores of shape [n, labels]
+// mapped through the label order, or BYTES labels of shape [n] scored 1.
+func (c *ClassifierJudge) parseKServe(body []byte, n int) ([][]labelScore, error) {
+	var resp struct {
+		Outputs []struct {
+			Shape    []int             `json:"shape"`
+			Datatype string            `json:"datatype"`
+			Data     []json.RawMessage `json:"data"`
+		} `json:"outputs"`
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("classifier error: %s", resp.Error)
+	}
+	if len(resp.Outputs) == 0 {
+		return nil, fmt.Errorf("no outputs in classifier response")
+	}
+	out := resp.Outputs[0]
+
+	scores := make([][]labelScore, n)
+	if out.Datatype == "BYTES" {
+		if len(out.Data) != n {
+			return nil, fmt.Errorf("classifier returned %d labels for %d inputs", len(out.Data), n)
+		}
+		for i, raw := range out.Data {
+			var label string
+			if err := json.Unmarshal(raw, &label); err != nil {
+				return nil, fmt.Errorf("failed to decode label: %w", err)
+			}
+			scores[i] = []labelScore{{Label: label, Score: 1}}
+		}
+		return scores, nil
+	}
+
+	k := len(c.labelOrder)
+	if k == 0 {
+		return nil, fmt.Errorf("KServe score tensor needs WithLabelOrder")
+	}
+	if len(out.Data) != n*k {
+		return nil, fmt.Errorf("classifier returned %d scores for %d inputs and %d labels", len(out.Data), n, k)
+	}
+	for i := range scores {
+		scores[i] = make([]labelScore, k)
+		for j, label := range c.labelOrder {
+			var score float64
+			if err := json.Unmarshal(out.Data[i*k+j], &score); err != nil {
+				return nil, fmt.Errorf("failed to decode score: %w", err)
+			}
+			scores[i][j] = labelScore{Label: label, Score: score}
+		}
+	}
+	return scores, nil
+}
+
+// truncateMiddle shortens s to at most max runes (0 = no limit) by cutting out
+// its middle, so both the start and the end reach the classifier.
+func truncateMiddle(s string, max int) string {
+	runes := []rune(s)
+	if max <= 0 || len(runes) <= max {
+		return s
+	}
+	head := max / 2
+	tail := max - head - 1
+	return string(runes[:head]) + "…" + string(runes[len(runes)-tail:])
+}
+
+func labelSet(labels []string) map[string]bool {
+	set := make(map[string]bool, len(labels))
+	for _, l := range labels {
+		set[strings.ToUpper(strings.TrimSpace(l))] = true
+	}
+	return set
+}
@@ -1,6 +1,11 @@
 package detector
 
-import "time"
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
 
 // NewOpenAIJudge creates an LLM judge for OpenAI API.
 func NewOpenAIJudge(apiKey, model string, opts ...LLMJudgeOption) LLMJudge {
@@ -8,7 +13,7 @@ func NewOpenAIJudge(apiKey, model string, opts ...LLMJudgeOption) LLMJudge {
 		"https://api.openai.com/v1/chat/completions",
 		apiKey,
 		model,
-		opts...,
+		withDefaults([]LLMJudgeOption{WithJSONSchema(true)}, opts)...,
 	)
 }
 
@@ -22,21 +27,68 @@ func NewOpenRouterJudge(apiKey, model string, opts ...LLMJudgeOption) LLMJudge {
 	)
 }
 
+// NewAzureOpenAIJudge creates an LLM judge for an Azure OpenAI deployment.
+// endpoint is the resource URL (e.g. https://my-resource.openai.azure.com) and
+// deployment the name given to the model deployment in Azure.
+// An empty apiVersion uses 2024-10-21.
+func NewAzureOpenAIJudge(endpoint, apiKey, deployment, apiVersion string, opts ...LLMJudgeOption) LLMJudge {
+	if apiVersion == "" {
+		apiVersion = "2024-10-21"
+	}
+	return newLLMJudge(
+		openAIChat{keyHeader: "api-key"},
+		fmt.Sprintf("%s/openai/deployments/%s/chat/completions?api-version=%s",
+			strings.TrimSuffix(endpoint, "/"), url.PathEscape(deployment), url.QueryEscape(apiVersion)),
+		apiKey,
+		deployment,
+		withDefaults([]LLMJudgeOption{WithJSONSchema(true)}, opts)...,
+	)
+}
+
+// NewGeminiJudge creates an LLM judge for the Google Gemini API.
+func NewGeminiJudge(apiKey, model string, opts ...LLMJudgeOption) LLMJudge {
+	return NewGeminiJudgeWithEndpoint("https://generativelanguage.googleapis.com/v1beta", apiKey, model, opts...)
+}
+
+// NewGeminiJudgeWithEndpoint creates a Gemini judge with custom base URL.
+// Useful behind an API gateway or proxy that forwards /models/{model}:generateContent.
+func NewGeminiJudgeWithEndpoint(endpoint, apiKey, model string, opts ...LLMJudgeOption) LLMJudge {
+	return newLLMJudge(
+		geminiGenerate{},
+		fmt.Sprintf("%s/models/%s:generateContent", strings.TrimSuffix(endpoint, "/"), url.PathEscape(model)),
+		apiKey,
+		model,
+		withDefaults([]LLMJudgeOption{WithJSONSchema(true)}, opts)...,
+	)
+}
+
+// NewAnthropicJudge creates an LLM judge for the Anthropic Messages API (Claude models).
+func NewAnthropicJudge(apiKey, model string, opts ...LLMJudgeOption) LLMJudge {
+	return NewAnthropicJudgeWithEndpoint("https://api.anthropic.com", apiKey, model, opts...)
+}
+
+// NewAnthropicJudgeWithEndpoint creates an Anthropic judge with custom base URL.
+// Useful behind an API gateway or proxy that forwards /v1/messages.
+func NewAnthropicJudgeWithEndpoint(endpoint, apiKey, model string, opts ...LLMJudgeOption) LLMJudge {
+	return newLLMJudge(
+		anthropicMessages{},
+		strings.TrimSuffix(endpoint, "/")+"/v1/messages",
+		apiKey,
+		model,
+		opts...,
+	)
+}
+
 // NewOllamaJudge creates an LLM judge for local Ollama models.
 // Default endpoint: http://localhost:11434.
 // Default timeout: 60s (local models are slower than remote APIs, especially on cold start).
 func NewOllamaJudge(model string, opts ...LLMJudgeOption) LLMJudge {
-	j := NewGenericLLMJudge(
+	return NewGenericLLMJudge(
 		"http://localhost:11434/v1/chat/completions",
 		"",
 		model,
-		opts...,
+		withDefaults([]LLMJudgeOption{WithLLMTimeout(60 * time.Second)}, opts)...,
 	)
-	// Only apply the higher default if the user didn't set their own timeout
-	if j.timeout == 10*time.Second {
-		WithLLMTimeout(60 * time.Second)(j)
-	}
-	return j
 }
 
 // NewOllamaJudgeWithEndpoint creates an Ollama judge with custom endpoint.
@@ -49,3 +101,8 @@ func NewOllamaJudgeWithEndpoint(endpoint, model string, opts ...LLMJudgeOption)
 		opts...,
 	)
 }
+
+// withDefaults puts provider defaults before the caller's options so the caller can override them.
+func withDefaults(defaults, opts []LLMJudgeOption) []LLMJudgeOption {
+	return append(defaults, opts...)
+}
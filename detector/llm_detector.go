@@ -11,10 +11,15 @@ type LLMDetector struct {
 	timeout time.Duration
 }
 
+// defaultLLMTimeout bounds a call to a judge that does not say how long it can take.
+const defaultLLMTimeout = 10 * time.Second
+
+// NewLLMDetector creates a detector that gives each judge call as long as the
+// judge can take, retries and fallbacks included (see judgeTimeout).
 func NewLLMDetector(judge LLMJudge) *LLMDetector {
 	return &LLMDetector{
 		judge:   judge,
-		timeout: 10 * time.Second,
+		timeout: judgeTimeout(judge),
 	}
 }
 
@@ -25,15 +30,57 @@ func NewLLMDetectorWithTimeout(judge LLMJudge, timeout time.Duration) *LLMDetect
 	}
 }
 
+// newStageDetector returns the LLM detector for cfg's judge, honouring cfg.LLMTimeout.
+func newStageDetector(cfg Config) *LLMDetector {
+	return NewLLMDetectorWithTimeout(cfg.LLMJudge, stageTimeout(cfg))
+}
+
+// stageTimeout is how long the LLM stage may take per request: cfg.LLMTimeout
+// if set, else the judge's own timeout.
+func stageTimeout(cfg Config) time.Duration {
+	if cfg.LLMTimeout > 0 {
+		return cfg.LLMTimeout
+	}
+	return judgeTimeout(cfg.LLMJudge)
+}
+
 func (d *LLMDetector) Detect(ctx context.Context, input string) Result {
 	ctx, cancel := context.WithTimeout(ctx, d.timeout)
 	defer cancel()
 
 	llmResult, err := d.judge.Judge(ctx, input)
+	return llmStageResult(llmResult, err)
+}
+
+// callTimeouter is implemented by judges that know how long one Judge call
+// can take, retries and fallbacks included.
+type callTimeouter interface {
+	callTimeout() time.Duration
+}
+
+// judgeTimeout returns how long one call to judge can take: its callTimeout,
+// else its GetTimeout, else defaultLLMTimeout.
+func judgeTimeout(judge LLMJudge) time.Duration {
+	var d time.Duration
+	switch j := judge.(type) {
+	case callTimeouter:
+		d = j.callTimeout()
+	case interface{ GetTimeout() time.Duration }:
+		d = j.GetTimeout()
+	}
+	if d <= 0 {
+		return defaultLLMTimeout
+	}
+	return d
+}
+
+// llmStageResult converts a judge verdict, or its error, into a Result.
+func llmStageResult(llmResult LLMResult, err error) Result {
 	if err != nil {
-		// On error, return safe result with low confidence
+		// On error, return safe result with low confidence unless the judge must fail closed
+		errs := []StageError{{Stage: StageLLM, Err: err}}
 		return Result{
-			Safe:       true,
+			Safe:       !stageFailsClosed(errs),
 			RiskScore:  0.0,
 			Confidence: 0.0,
 			DetectedPatterns: []DetectedPattern{
@@ -43,6 +90,8 @@ func (d *LLMDetector) Detect(ctx context.Context, input string) Result {
 					Matches: []string{err.Error()},
 				},
 			},
+			Incomplete: true,
+			Errors:     errs,
 		}
 	}
 
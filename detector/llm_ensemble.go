@@ -0,0 +1,312 @@
+package detector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EnsembleRule decides how member verdicts combine into one.
+type EnsembleRule int
+
+const (
+	// EnsembleMajority flags an attack when at least half of the answering members
+	// (by weight) say attack. Ties count as attack.
+	EnsembleMajority EnsembleRule = iota
+
+	// EnsembleUnanimous flags an attack only when every answering member says attack.
+	// Fewest false positives, most misses.
+	EnsembleUnanimous
+
+	// EnsembleWeightedAverage averages each member's attack probability (its
+	// confidence, or 1-confidence for SAFE) by weight and flags an attack at >= 0.5.
+	EnsembleWeightedAverage
+
+	// EnsembleKOfN flags an attack when at least K answering members say attack.
+	// Set K with WithEnsembleK; K=1 flags if any member does.
+	EnsembleKOfN
+)
+
+// EnsembleMember is one judge in an EnsembleJudge.
+type EnsembleMember struct {
+	Judge LLMJudge
+
+	// Name labels the member in Reasoning. Default: "judge N".
+	Name string
+
+	// Weight of the member's vote. Default: 1.
+	Weight float64
+
+	// Timeout drops the member's vote if it has not answered in time.
+	// Default: the ensemble's member timeout (see WithMemberTimeout), or none.
+	Timeout time.Duration
+}
+
+// EnsembleJudge queries several judges in parallel and combines their verdicts.
+// Members that fail or exceed their latency cap are dropped from the vote.
+type EnsembleJudge struct {
+	members       []EnsembleMember
+	rule          EnsembleRule
+	k             int
+	memberTimeout time.Duration
+}
+
+// EnsembleOption configures an EnsembleJudge.
+type EnsembleOption func(*EnsembleJudge)
+
+// WithEnsembleRule sets how votes combine. Default is EnsembleMajority.
+func WithEnsembleRule(rule EnsembleRule) EnsembleOption {
+	return func(e *EnsembleJudge) {
+		e.rule = rule
+	}
+}
+
+// WithEnsembleK switches to EnsembleKOfN with the given K.
+func WithEnsembleK(k int) EnsembleOption {
+	return func(e *EnsembleJudge) {
+		if k > 0 {
+			e.rule = EnsembleKOfN
+			e.k = k
+		}
+	}
+}
+
+// WithMemberTimeout caps how long any member may take. Members with their own
+// Timeout keep it. Default: no cap beyond the caller's context.
+func WithMemberTimeout(timeout time.Duration) EnsembleOption {
+	return func(e *EnsembleJudge) {
+		if timeout > 0 {
+			e.memberTimeout = timeout
+		}
+	}
+}
+
+// NewEnsembleJudge creates a judge that votes across members.
+//
+// Example:
+//
+//	judge := detector.NewEnsembleJudge([]detector.EnsembleMember{
+//	    {Name: "gpt", Judge: detector.NewOpenAIJudge(openaiKey, "gpt-5"), Weight: 2},
+//	    {Name: "claude", Judge: detector.NewAnthropicJudge(anthropicKey, "claude-sonnet-4-5"), Weight: 2},
+//	    {Name: "local", Judge: detector.NewOllamaJudge("llama3.1:8b"), Timeout: 3 * time.Second},
+//	}, detector.WithEnsembleRule(detector.EnsembleWeightedAverage))
+func NewEnsembleJudge(members []EnsembleMember, opts ...EnsembleOption) *EnsembleJudge {
+	e := &EnsembleJudge{
+		members: make([]EnsembleMember, len(members)),
+		rule:    EnsembleMajority,
+		k:       1,
+	}
+	for i, m := range members {
+		if m.Name == "" {
+			m.Name = fmt.Sprintf("judge %d", i+1)
+		}
+		if m.Weight <= 0 {
+			m.Weight = 1
+		}
+		e.members[i] = m
+	}
+
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+type memberVote struct {
+	result LLMResult
+	err    error
+}
+
+// callTimeout is the longest member timeout, as members run in parallel.
+func (e *EnsembleJudge) callTimeout() time.Duration {
+	var longest time.Duration
+	for _, m := range e.members {
+		d := m.Timeout
+		if d == 0 {
+			d = e.memberTimeout
+		}
+		if d == 0 {
+			d = judgeTimeout(m.Judge)
+		}
+		longest = max(longest, d)
+	}
+	return longest
+}
+
+// Judge asks every member in parallel and combines the answers by the ensemble rule.
+// Confidence is the weighted share of answering members that agree with the
+// verdict, scaled by their own confidence. Usage is summed over every member,
+// failed ones included.
+// Returns an error only if no member answered.
+func (e *EnsembleJudge) Judge(ctx context.Context, input string) (LLMResult, error) {
+	if len(e.members) == 0 {
+		return LLMResult{}, errors.New("no LLM judges configured")
+	}
+
+	votes := make([]memberVote, len(e.members))
+	var wg sync.WaitGroup
+	for i, m := range e.members {
+		wg.Add(1)
+		go func(i int, m EnsembleMember) {
+			defer wg.Done()
+			memberCtx := ctx
+			timeout := m.Timeout
+			if timeout == 0 {
+				timeout = e.memberTimeout
+			}
+			if timeout > 0 {
+				var cancel context.CancelFunc
+				memberCtx, cancel = context.WithTimeout(ctx, timeout)
+				defer cancel()
+			}
+			votes[i].result, votes[i].err = e.judgeMember(memberCtx, m.Judge, input)
+		}(i, m)
+	}
+	wg.Wait()
+
+	var totalWeight, attackWeight, attackProb float64
+	var usage TokenUsage
+	attacks, answered := 0, 0
+	var errs []error
+	for i, v := range votes {
+		usage = usage.add(v.result.Usage)
+		if v.err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", e.members[i].Name, v.err))
+			continue
+		}
+		w := e.members[i].Weight
+		answered++
+		totalWeight += w
+		if v.result.IsAttack {
+			attacks++
+			attackWeight += w
+			attackProb += w * v.result.Confidence
+		} else {
+			attackProb += w * (1 - v.result.Confidence)
+		}
+	}
+	if answered == 0 {
+		return LLMResult{Usage: usage}, errors.Join(errs...)
+	}
+
+	var isAttack bool
+	switch e.rule {
+	case EnsembleUnanimous:
+		isAttack = attacks == answered
+	case EnsembleWeightedAverage:
+		isAttack = attackProb/totalWeight >= 0.5
+	case EnsembleKOfN:
+		isAttack = attacks >= e.k
+	default:
+		isAttack = attackWeight*2 >= totalWeight
+	}
+
+	result := LLMResult{IsAttack: isAttack, Usage: usage}
+	if e.rule == EnsembleWeightedAverage {
+		result.Confidence = attackProb / totalWeight
+		if !isAttack {
+			result.Confidence = 1 - result.Confidence
+		}
+	} else {
+		var agreement float64
+		for i, v := range votes {
+			if v.err == nil && v.result.IsAttack == isAttack {
+				agreement += e.members[i].Weight * v.result.Confidence
+			}
+		}
+		result.Confidence = agreement / totalWeight
+	}
+	result.Confidence = round(result.Confidence, 2)
+
+	result.AttackType = e.attackType(votes, isAttack)
+	result.Reasoning = e.reasoning(votes)
+	return result, nil
+}
+
+// judgeMember runs one member, returning early if its context ends first so a
+// judge that ignores cancellation cannot hold up the vote.
+func (e *EnsembleJudge) judgeMember(ctx context.Context, judge LLMJudge, input string) (LLMResult, error) {
+	done := make(chan memberVote, 1)
+	go func() {
+		result, err := judge.Judge(ctx, input)
+		done <- memberVote{result, err}
+	}()
+
+	select {
+	case v := <-done:
+		return v.result, v.err
+	case <-ctx.Done():
+		return LLMResult{}, ctx.Err()
+	}
+}
+
+// attackType picks the attack type most (by weight) attack-voting members reported.
+func (e *EnsembleJudge) attackType(votes []memberVote, isAttack bool) string {
+	if !isAttack {
+		return ""
+	}
+	weights := make(map[string]float64)
+	best := ""
+	for i, v := range votes {
+		t := v.result.AttackType
+		if v.err != nil || !v.result.IsAttack || t == "" || t == "none" {
+			continue
+		}
+		weights[t] += e.members[i].Weight
+		if best == "" || weights[t] > weights[best] {
+			best = t
+		}
+	}
+	return best
+}
+
+// reasoning lists each member's verdict, e.g. "gpt: ATTACK (0.90) asks for the system prompt; local: dropped (context deadline exceeded)".
+func (e *EnsembleJudge) reasoning(votes []memberVote) string {
+	parts := make([]string, len(votes))
+	for i, v := range votes {
+		name := e.members[i].Name
+		if v.err != nil {
+			parts[i] = fmt.Sprintf("%s: dropped (%v)", name, v.err)
+			continue
+		}
+		verdict := "SAFE"
+		if v.result.IsAttack {
+			verdict = "ATTACK"
+		}
+		parts[i] = fmt.Sprintf("%s: %s (%.2f)", name, verdict, v.result.Confidence)
+		if v.result.Reasoning != "" {
+			parts[i] += " " + v.result.Reasoning
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+// CacheIdentity covers the rule and each member's name, weight, timeout and judge identity.
+func (e *EnsembleJudge) CacheIdentity() string {
+	parts := make([]string, 0, len(e.members))
+	for _, m := range e.members {
+		id := cacheIdentity(m.Judge)
+		if id == "" {
+			return ""
+		}
+		parts = append(parts, joinIdentity(fmt.Sprintf("%s/%g/%s", m.Name, m.Weight, m.Timeout), id))
+	}
+	kind := fmt.Sprintf("ensemble/%d/%d/%s", e.rule, e.k, e.memberTimeout)
+	return joinIdentity(kind, parts...)
+}
+
+// Warmup warms every member in parallel.
+func (e *EnsembleJudge) Warmup(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, m := range e.members {
+		wg.Add(1)
+		go func(judge LLMJudge) {
+			defer wg.Done()
+			judge.Warmup(ctx)
+		}(m.Judge)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,123 @@
+package detector
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// slowJudge answers after delay, ignoring cancellation.
+type slowJudge struct {
+	delay  time.Duration
+	result LLMResult
+}
+
+func (j *slowJudge) Judge(ctx context.Context, input string) (LLMResult, error) {
+	time.Sleep(j.delay)
+	return j.result, nil
+}
+
+func (j *slowJudge) Warmup(ctx context.Context) {}
+
+func attackVote(conf float64, attackType string) *MockLLMJudge {
+	return &MockLLMJudge{result: LLMResult{IsAttack: true, Confidence: conf, AttackType: attackType}}
+}
+
+func safeVote(conf float64) *MockLLMJudge {
+	return &MockLLMJudge{result: LLMResult{IsAttack: false, Confidence: conf}}
+}
+
+func TestEnsembleJudge_Majority(t *testing.T) {
+	judge := NewEnsembleJudge([]EnsembleMember{
+		{Name: "a", Judge: attackVote(0.9, "prompt_leak")},
+		{Name: "b", Judge: attackVote(0.6, "role_injection")},
+		{Name: "c", Judge: safeVote(0.8)},
+	})
+
+	result, err := judge.Judge(context.Background(), "input")
+	require.NoError(t, err)
+	assert.True(t, result.IsAttack)
+	assert.Equal(t, 0.5, result.Confidence, "(0.9 + 0.6) / 3 members")
+	assert.Equal(t, "prompt_leak", result.AttackType)
+	assert.Equal(t, "a: ATTACK (0.90); b: ATTACK (0.60); c: SAFE (0.80)", result.Reasoning)
+}
+
+func TestEnsembleJudge_Rules(t *testing.T) {
+	members := []EnsembleMember{
+		{Judge: attackVote(0.9, "")},
+		{Judge: safeVote(0.7)},
+		{Judge: safeVote(0.6)},
+	}
+
+	tests := []struct {
+		name       string
+		opts       []EnsembleOption
+		isAttack   bool
+		confidence float64
+	}{
+		{"majority", nil, false, 0.43},
+		{"unanimous", []EnsembleOption{WithEnsembleRule(EnsembleUnanimous)}, false, 0.43},
+		{"1 of n", []EnsembleOption{WithEnsembleK(1)}, true, 0.3},
+		{"2 of n", []EnsembleOption{WithEnsembleK(2)}, false, 0.43},
+		// attack probabilities 0.9, 0.3, 0.4 average to 0.53
+		{"weighted average", []EnsembleOption{WithEnsembleRule(EnsembleWeightedAverage)}, true, 0.53},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NewEnsembleJudge(members, tt.opts...).Judge(context.Background(), "input")
+			require.NoError(t, err)
+			assert.Equal(t, tt.isAttack, result.IsAttack)
+			assert.Equal(t, tt.confidence, result.Confidence)
+		})
+	}
+}
+
+func TestEnsembleJudge_Weights(t *testing.T) {
+	judge := NewEnsembleJudge([]EnsembleMember{
+		{Judge: attackVote(1.0, ""), Weight: 3},
+		{Judge: safeVote(1.0)},
+		{Judge: safeVote(1.0)},
+	})
+
+	result, err := judge.Judge(context.Background(), "input")
+	require.NoError(t, err)
+	assert.True(t, result.IsAttack, "weight 3 outvotes two weight-1 members")
+	assert.Equal(t, 0.6, result.Confidence)
+}
+
+func TestEnsembleJudge_DropsSlowAndFailingMembers(t *testing.T) {
+	judge := NewEnsembleJudge([]EnsembleMember{
+		{Name: "fast", Judge: attackVote(0.8, "")},
+		{Name: "slow", Judge: &slowJudge{delay: 200 * time.Millisecond, result: LLMResult{Confidence: 1}}, Timeout: 10 * time.Millisecond},
+		{Name: "broken", Judge: &MockLLMJudge{err: errors.New("503")}},
+	}, WithEnsembleRule(EnsembleUnanimous))
+
+	start := time.Now()
+	result, err := judge.Judge(context.Background(), "input")
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), 150*time.Millisecond)
+
+	assert.True(t, result.IsAttack, "only the fast member answered")
+	assert.Equal(t, 0.8, result.Confidence)
+	assert.Contains(t, result.Reasoning, "slow: dropped (context deadline exceeded)")
+	assert.Contains(t, result.Reasoning, "broken: dropped (503)")
+}
+
+func TestEnsembleJudge_AllFail(t *testing.T) {
+	judge := NewEnsembleJudge([]EnsembleMember{
+		{Judge: &MockLLMJudge{err: errors.New("down")}},
+		{Judge: &slowJudge{delay: 100 * time.Millisecond}},
+	}, WithMemberTimeout(5*time.Millisecond))
+
+	_, err := judge.Judge(context.Background(), "input")
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	_, err = NewEnsembleJudge(nil).Judge(context.Background(), "input")
+	assert.Error(t, err)
+}
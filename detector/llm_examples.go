@@ -0,0 +1,229 @@
+package detector
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/mdombrov-33/go-promptguard/dataset"
+)
+
+// Example is a labeled input shown to the judge as a prior conversation turn,
+// so it learns from cases specific to your product without a custom prompt.
+type Example struct {
+	Input      string
+	IsAttack   bool
+	AttackType string // structured format only; "" or an unknown type shows "other" for attacks
+	Reasoning  string // structured format only; defaults to the label
+}
+
+// ExampleRetriever picks up to k examples relevant to an input.
+type ExampleRetriever interface {
+	Retrieve(input string, k int) []Example
+}
+
+// NGramRetriever returns the labeled examples most similar to the input,
+// measured as the Jaccard similarity of their character trigrams.
+type NGramRetriever struct {
+	examples []Example
+	grams    []map[string]struct{}
+	identity string
+}
+
+// NewNGramRetriever indexes examples for similarity search.
+func NewNGramRetriever(examples []Example) *NGramRetriever {
+	r := &NGramRetriever{
+		examples: examples,
+		grams:    make([]map[string]struct{}, len(examples)),
+		identity: exampleDigest(examples),
+	}
+	for i, ex := range examples {
+		r.grams[i] = trigrams(ex.Input)
+	}
+	return r
+}
+
+// LoadExampleRetriever indexes the labeled samples of a dataset file (see dataset.Load).
+// Attack samples use their category as the attack type and their notes as the reasoning.
+//
+// Example:
+//
+//	retriever, err := detector.LoadExampleRetriever("support_bot_labeled.jsonl")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	judge := detector.NewOpenAIJudge(apiKey, "gpt-5",
+//	    detector.WithExampleRetriever(retriever, 4),
+//	)
+func LoadExampleRetriever(path string, opts ...dataset.Option) (*NGramRetriever, error) {
+	samples, err := dataset.Load(path, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	examples := make([]Example, 0, len(samples))
+	for _, s := range samples {
+		if !s.Labeled() {
+			continue
+		}
+		ex := Example{Input: s.Input, IsAttack: s.IsAttack()}
+		if ex.IsAttack {
+			ex.AttackType = s.Category
+			ex.Reasoning = s.Notes
+		}
+		examples = append(examples, ex)
+	}
+	if len(examples) == 0 {
+		return nil, fmt.Errorf("no labeled samples in %s", path)
+	}
+	return NewNGramRetriever(examples), nil
+}
+
+// Retrieve returns up to k examples sharing at least one trigram with the
+// input, most similar first.
+func (r *NGramRetriever) Retrieve(input string, k int) []Example {
+	if k <= 0 {
+		return nil
+	}
+	grams := trigrams(input)
+
+	type scored struct {
+		index int
+		score float64
+	}
+	var candidates []scored
+	for i, g := range r.grams {
+		if score := jaccard(grams, g); score > 0 {
+			candidates = append(candidates, scored{i, score})
+		}
+	}
+	sort.SliceStable(candidates, func(a, b int) bool {
+		return candidates[a].score > candidates[b].score
+	})
+
+	if len(candidates) > k {
+		candidates = candidates[:k]
+	}
+	examples := make([]Example, len(candidates))
+	for i, c := range candidates {
+		examples[i] = r.examples[c.index]
+	}
+	return examples
+}
+
+// CacheIdentity changes whenever the indexed examples do.
+func (r *NGramRetriever) CacheIdentity() string {
+	return r.identity
+}
+
+// trigrams returns the character trigrams of the lowercased input with
+// whitespace collapsed. Inputs shorter than three characters are one gram.
+func trigrams(input string) map[string]struct{} {
+	runes := []rune(strings.Join(strings.Fields(strings.ToLower(input)), " "))
+	grams := make(map[string]struct{})
+	if len(runes) == 0 {
+		return grams
+	}
+	if len(runes) < 3 {
+		grams[string(runes)] = struct{}{}
+		return grams
+	}
+	for i := 0; i+3 <= len(runes); i++ {
+		grams[string(runes[i:i+3])] = struct{}{}
+	}
+	return grams
+}
+
+func jaccard(a, b map[string]struct{}) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	shared := 0
+	for g := range a {
+		if _, ok := b[g]; ok {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
+
+// exampleDigest is a short stable hash of examples for cache identities.
+func exampleDigest(examples []Example) string {
+	h := sha256.New()
+	for _, ex := range examples {
+		fmt.Fprintf(h, "%q\x00%t\x00%q\x00%q\n", ex.Input, ex.IsAttack, ex.AttackType, ex.Reasoning)
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// exampleTurns renders the few-shot and retrieved examples for input as
+// user/assistant turns in the same format as the real request.
+func (j *GenericLLMJudge) exampleTurns(input, nonce string) []chatMessage {
+	examples := j.examples
+	if j.retriever != nil {
+		examples = append(append([]Example{}, examples...), j.retriever.Retrieve(input, j.retrieveK)...)
+	}
+
+	turns := make([]chatMessage, 0, 2*len(examples))
+	for _, ex := range examples {
+		turns = append(turns,
+			chatMessage{Role: "user", Content: j.userPrompt(ex.Input, nonce)},
+			chatMessage{Role: "assistant", Content: j.exampleReply(ex, nonce)},
+		)
+	}
+	return turns
+}
+
+// exampleAttackType maps an example's attack type onto a value the judge may
+// answer: a known type or category, or "other". With WithJSONSchema categories
+// become "other" too, as the schema only lists specific types.
+func (j *GenericLLMJudge) exampleAttackType(attackType string) string {
+	t := normalizeAttackType(attackType)
+	if t == "" || t == "none" || (j.jsonSchema && !slices.Contains(attackTypes, t)) {
+		return otherAttackType
+	}
+	return t
+}
+
+// exampleReply is the answer the judge should give for ex.
+func (j *GenericLLMJudge) exampleReply(ex Example, nonce string) string {
+	label := "SAFE"
+	if ex.IsAttack {
+		label = "ATTACK"
+	}
+	if j.outputFormat == LLMSimple {
+		if j.hardened {
+			return verdictMarker(nonce) + " " + label
+		}
+		return label
+	}
+
+	reply := struct {
+		IsAttack   bool    `json:"is_attack"`
+		Confidence float64 `json:"confidence"`
+		AttackType string  `json:"attack_type"`
+		Reasoning  string  `json:"reasoning"`
+		Nonce      string  `json:"nonce,omitempty"`
+	}{
+		IsAttack:   ex.IsAttack,
+		Confidence: 0.95,
+		AttackType: "none",
+		Reasoning:  ex.Reasoning,
+		Nonce:      nonce,
+	}
+	if ex.IsAttack {
+		reply.AttackType = j.exampleAttackType(ex.AttackType)
+	}
+	if reply.Reasoning == "" {
+		reply.Reasoning = "Labeled " + strings.ToLower(label) + " example"
+	}
+	data, _ := json.Marshal(reply)
+	return string(data)
+}
@@ -0,0 +1,146 @@
+package detector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// FailoverJudge tries an ordered list of judges and returns the first successful verdict.
+// Pair it with WithCircuitBreaker on the primary so a provider outage costs one
+// fast ErrCircuitOpen instead of a timeout per input.
+type FailoverJudge struct {
+	judges []LLMJudge
+}
+
+// NewFailoverJudge creates a judge that falls back through judges in order,
+// e.g. a hosted model first and a local Ollama model as backup.
+//
+// Example:
+//
+//	judge := detector.NewFailoverJudge(
+//	    detector.NewOpenAIJudge(apiKey, "gpt-5", detector.WithCircuitBreaker(3, time.Minute)),
+//	    detector.NewOllamaJudge("llama3.1:8b"),
+//	)
+func NewFailoverJudge(judges ...LLMJudge) *FailoverJudge {
+	return &FailoverJudge{judges: judges}
+}
+
+// Judge returns the first successful result, with Usage summed over every judge
+// that ran. If every judge fails, the error lists all failures.
+func (f *FailoverJudge) Judge(ctx context.Context, input string) (LLMResult, error) {
+	if len(f.judges) == 0 {
+		return LLMResult{}, errors.New("no LLM judges configured")
+	}
+
+	var errs []error
+	var usage TokenUsage
+	for i, judge := range f.judges {
+		result, err := judge.Judge(ctx, input)
+		usage = usage.add(result.Usage)
+		if err == nil {
+			result.Usage = usage
+			return result, nil
+		}
+		errs = append(errs, fmt.Errorf("judge %d: %w", i+1, err))
+
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return LLMResult{Usage: usage}, errors.Join(errs...)
+}
+
+// JudgeBatch is Judge for several inputs: each judge gets the inputs the
+// judges before it failed on, in one JudgeBatch call if it is a BatchJudge.
+func (f *FailoverJudge) JudgeBatch(ctx context.Context, inputs []string) ([]LLMResult, error) {
+	results := make([]LLMResult, len(inputs))
+	failures := make([][]error, len(inputs))
+	pending := make([]int, len(inputs))
+	for i := range pending {
+		pending[i] = i
+	}
+
+	for n, judge := range f.judges {
+		if len(pending) == 0 || (n > 0 && ctx.Err() != nil) {
+			break
+		}
+		texts := make([]string, len(pending))
+		for k, i := range pending {
+			texts[k] = inputs[i]
+		}
+		got, errs := judgeEach(ctx, judge, texts)
+
+		var failed []int
+		for k, i := range pending {
+			usage := results[i].Usage.add(got[k].Usage)
+			if errs[k] != nil {
+				failures[i] = append(failures[i], fmt.Errorf("judge %d: %w", n+1, errs[k]))
+				results[i].Usage = usage
+				failed = append(failed, i)
+				continue
+			}
+			results[i] = got[k]
+			results[i].Usage = usage
+		}
+		pending = failed
+	}
+
+	errs := make([]error, len(inputs))
+	for _, i := range pending {
+		errs[i] = errors.Join(failures[i]...)
+		if errs[i] == nil {
+			errs[i] = errors.New("no LLM judges configured")
+		}
+	}
+	return results, batchErrors(errs)
+}
+
+// batchLimit is the smallest limit among the judges that batch.
+func (f *FailoverJudge) batchLimit() int {
+	limit := 0
+	for _, judge := range f.judges {
+		if n := batchLimit(judge); batches(judge) && n > 0 && (limit == 0 || n < limit) {
+			limit = n
+		}
+	}
+	return limit
+}
+
+func (f *FailoverJudge) wrapsBatchJudge() bool {
+	for _, judge := range f.judges {
+		if batches(judge) {
+			return true
+		}
+	}
+	return false
+}
+
+// callTimeout is the sum of the judges' timeouts, so a hanging judge still
+// leaves time for the next one.
+func (f *FailoverJudge) callTimeout() time.Duration {
+	var total time.Duration
+	for _, judge := range f.judges {
+		total += judgeTimeout(judge)
+	}
+	return total
+}
+
+// CacheIdentity lists the judges' identities in order.
+func (f *FailoverJudge) CacheIdentity() string {
+	ids := make([]string, len(f.judges))
+	for i, judge := range f.judges {
+		if ids[i] = cacheIdentity(judge); ids[i] == "" {
+			return ""
+		}
+	}
+	return joinIdentity("failover", ids...)
+}
+
+// Warmup warms every judge so a failover does not hit a cold model.
+func (f *FailoverJudge) Warmup(ctx context.Context) {
+	for _, judge := range f.judges {
+		judge.Warmup(ctx)
+	}
+}
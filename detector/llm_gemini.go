@@ -0,0 +1,139 @@
+package detector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// geminiContent is the generateContent message shape: a role plus text parts.
+type geminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []geminiPart `json:"parts"`
+}
+
+type geminiPart struct {
+	Text    string `json:"text"`
+	Thought bool   `json:"thought,omitempty"`
+}
+
+// geminiGenerate is the Google Gemini generateContent format. The system prompt is
+// a systemInstruction and assistant turns use the "model" role.
+type geminiGenerate struct{}
+
+func (geminiGenerate) newRequest(ctx context.Context, j *GenericLLMJudge, messages []chatMessage) (*http.Request, error) {
+	contents := make([]geminiContent, 0, len(messages))
+	for _, m := range messages {
+		role := m.Role
+		if role == "assistant" {
+			role = "model"
+		}
+		contents = append(contents, geminiContent{Role: role, Parts: []geminiPart{{Text: m.Content}}})
+	}
+
+	payload := map[string]interface{}{
+		"systemInstruction": geminiContent{Parts: []geminiPart{{Text: j.systemPrompt}}},
+		"contents":          contents,
+	}
+
+	generationConfig := map[string]interface{}{}
+	if j.temperature != nil {
+		generationConfig["temperature"] = *j.temperature
+	}
+	if j.outputFormat == LLMStructured {
+		generationConfig["responseMimeType"] = "application/json"
+		if j.jsonSchema {
+			generationConfig["responseSchema"] = geminiSchema(verdictSchema(j.hardened))
+		}
+	}
+	if len(generationConfig) > 0 {
+		payload["generationConfig"] = generationConfig
+	}
+
+	req, err := newJSONRequest(ctx, j.endpoint, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	if j.apiKey != "" {
+		req.Header.Set("x-goog-api-key", j.apiKey)
+	}
+	return req, nil
+}
+
+// geminiSchema converts a JSON schema to Gemini's OpenAPI subset: upper-case
+// type names and no additionalProperties.
+func geminiSchema(schema map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(schema))
+	for k, v := range schema {
+		switch k {
+		case "additionalProperties":
+			continue
+		case "type":
+			out[k] = strings.ToUpper(v.(string))
+		case "properties":
+			props := make(map[string]interface{})
+			for name, p := range v.(map[string]interface{}) {
+				props[name] = geminiSchema(p.(map[string]interface{}))
+			}
+			out[k] = props
+		default:
+			out[k] = v
+		}
+	}
+	return out
+}
+
+func (geminiGenerate) parseResponse(body []byte) (chatReply, error) {
+	var apiResp struct {
+		Candidates []struct {
+			Content      geminiContent `json:"content"`
+			FinishReason string        `json:"finishReason"`
+		} `json:"candidates"`
+		PromptFeedback struct {
+			BlockReason string `json:"blockReason"`
+		} `json:"promptFeedback"`
+		UsageMetadata struct {
+			PromptTokenCount     int `json:"promptTokenCount"`
+			CandidatesTokenCount int `json:"candidatesTokenCount"`
+			ThoughtsTokenCount   int `json:"thoughtsTokenCount"`
+			TotalTokenCount      int `json:"totalTokenCount"`
+		} `json:"usageMetadata"`
+	}
+
+	if err := json.Unmarshal(body, &apiResp); err != nil {
+		return chatReply{}, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if len(apiResp.Candidates) == 0 {
+		if apiResp.PromptFeedback.BlockReason != "" {
+			return chatReply{}, fmt.Errorf("LLM blocked the input: %s", apiResp.PromptFeedback.BlockReason)
+		}
+		return chatReply{}, fmt.Errorf("no response from LLM")
+	}
+
+	candidate := apiResp.Candidates[0]
+	var text strings.Builder
+	for _, part := range candidate.Content.Parts {
+		if !part.Thought {
+			text.WriteString(part.Text)
+		}
+	}
+
+	if text.Len() == 0 {
+		if candidate.FinishReason != "" && candidate.FinishReason != "STOP" {
+			return chatReply{}, fmt.Errorf("no response from LLM (finish reason %s)", candidate.FinishReason)
+		}
+		return chatReply{}, fmt.Errorf("no response from LLM")
+	}
+
+	// Thinking tokens are billed as output
+	usage := TokenUsage{
+		PromptTokens:     apiResp.UsageMetadata.PromptTokenCount,
+		CompletionTokens: apiResp.UsageMetadata.CandidatesTokenCount + apiResp.UsageMetadata.ThoughtsTokenCount,
+		TotalTokens:      apiResp.UsageMetadata.TotalTokenCount,
+	}
+	return chatReply{text: text.String(), usage: usage}, nil
+}
@@ -4,41 +4,77 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
+	"regexp"
 	"strings"
 	"time"
 )
 
-// GenericLLMJudge implements LLMJudge using OpenAI-compatible API endpoints.
+// GenericLLMJudge implements LLMJudge over a chat API.
+// It speaks the OpenAI chat-completions format unless a constructor picks another provider API.
 type GenericLLMJudge struct {
+	api          chatAPI
 	endpoint     string
 	apiKey       string
 	model        string
 	outputFormat LLMOutputFormat
 	systemPrompt string
 	timeout      time.Duration
-	httpClient   *http.Client
+	httpClient   *http.Client      // built from baseClient, transport and timeout after the options
+	baseClient   *http.Client      // WithHTTPClient
+	transport    http.RoundTripper // WithTransport
+	retry        retryPolicy
+	breaker      *circuitBreaker
+	temperature  *float64 // nil = provider default
+	logprobs     bool
+	hardened     bool
+	hardeningSet bool // WithPromptHardening was given
+	marking      InputMarking
+	jsonSchema   bool
+	examples     []Example
+	retriever    ExampleRetriever
+	retrieveK    int
+	batchSize    int
+	headers      map[string]string
+	hooks        []func(*http.Request) error
+	appContext   *applicationContext
 }
 
 func NewGenericLLMJudge(endpoint, apiKey, model string, opts ...LLMJudgeOption) *GenericLLMJudge {
+	return newLLMJudge(openAIChat{}, endpoint, apiKey, model, opts...)
+}
+
+func newLLMJudge(api chatAPI, endpoint, apiKey, model string, opts ...LLMJudgeOption) *GenericLLMJudge {
 	judge := &GenericLLMJudge{
+		api:          api,
 		endpoint:     endpoint,
 		apiKey:       apiKey,
 		model:        model,
 		outputFormat: LLMSimple, // Default to cheap mode
 		systemPrompt: "",        // Will be set based on format
 		timeout:      10 * time.Second,
-		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
+		retry: retryPolicy{
+			maxRetries: 0, // opt in with WithRetries, which also lengthens the LLM stage deadline
+			baseDelay:  250 * time.Millisecond,
+			maxDelay:   5 * time.Second,
 		},
+		hardened:  true,
+		marking:   InputDatamark,
+		batchSize: 20,
 	}
 
 	for _, opt := range opts {
 		opt(judge)
 	}
+	judge.httpClient = newHTTPClient(judge.baseClient, judge.transport, judge.timeout)
 
+	// Custom prompts were written for a bare SAFE/ATTACK reply, so they only get
+	// the nonce-bound format when asked for
+	if judge.systemPrompt != "" && !judge.hardeningSet {
+		judge.hardened = false
+	}
 	if judge.systemPrompt == "" {
 		if judge.outputFormat == LLMSimple {
 			judge.systemPrompt = defaultSimplePrompt()
@@ -46,10 +82,29 @@ func NewGenericLLMJudge(endpoint, apiKey, model string, opts ...LLMJudgeOption)
 			judge.systemPrompt = defaultStructuredPrompt()
 		}
 	}
+	if judge.appContext != nil {
+		judge.systemPrompt += judge.appContext.prompt(judge.outputFormat)
+	}
 
 	return judge
 }
 
+// newHTTPClient copies base (if any), swaps in transport (if any) and applies
+// timeout unless base sets its own, so the result does not depend on option order.
+func newHTTPClient(base *http.Client, transport http.RoundTripper, timeout time.Duration) *http.Client {
+	var c http.Client
+	if base != nil {
+		c = *base
+	}
+	if transport != nil {
+		c.Transport = transport
+	}
+	if c.Timeout == 0 {
+		c.Timeout = timeout
+	}
+	return &c
+}
+
 // Warmup pre-loads the model by sending a dummy request.
 // Call it as a goroutine right after creating an Ollama judge so the model
 // is ready in memory before real inputs arrive: go judge.Warmup(ctx)
@@ -62,6 +117,12 @@ func (j *GenericLLMJudge) GetTimeout() time.Duration {
 	return j.timeout
 }
 
+// callTimeout covers every attempt and the longest backoff between them.
+func (j *GenericLLMJudge) callTimeout() time.Duration {
+	retries := time.Duration(j.retry.maxRetries)
+	return j.timeout*(retries+1) + j.retry.maxDelay*retries
+}
+
 // GetOutputFormat returns the configured output format
 func (j *GenericLLMJudge) GetOutputFormat() LLMOutputFormat {
 	return j.outputFormat
@@ -72,70 +133,283 @@ func (j *GenericLLMJudge) GetSystemPrompt() string {
 	return j.systemPrompt
 }
 
+// CacheIdentity identifies everything besides the input that affects the verdict,
+// so CachingJudge never reuses results across models, prompts or endpoints.
+func (j *GenericLLMJudge) CacheIdentity() string {
+	id := fmt.Sprintf("%s\x00%s\x00%d\x00%s", j.endpoint, j.model, j.outputFormat, j.systemPrompt)
+	if j.temperature != nil {
+		id += fmt.Sprintf("\x00temperature=%g", *j.temperature)
+	}
+	if j.logprobs {
+		id += "\x00logprobs"
+	}
+	if j.hardened {
+		id += fmt.Sprintf("\x00hardened=%d", j.marking)
+	}
+	if j.jsonSchema {
+		id += "\x00json_schema"
+	}
+	if len(j.examples) > 0 {
+		id += "\x00examples=" + exampleDigest(j.examples)
+	}
+	if j.retriever != nil {
+		retriever := fmt.Sprintf("%T", j.retriever)
+		if r, ok := j.retriever.(CacheIdentifier); ok {
+			retriever = r.CacheIdentity()
+		}
+		id += fmt.Sprintf("\x00retriever=%s/%d", retriever, j.retrieveK)
+	}
+	return id
+}
+
 // Judge sends the input to the LLM API and returns the classification result
 func (j *GenericLLMJudge) Judge(ctx context.Context, input string) (LLMResult, error) {
-	payload := map[string]interface{}{
-		"model": j.model,
-		"messages": []map[string]string{
-			{"role": "system", "content": j.systemPrompt},
-			{"role": "user", "content": buildUserPrompt(input)},
-		},
-		"temperature": 1,
+	var nonce string
+	if j.hardened {
+		nonce = newNonce()
 	}
+	messages := append(j.exampleTurns(input, nonce), chatMessage{Role: "user", Content: j.userPrompt(input, nonce)})
 
-	if j.outputFormat == LLMStructured {
-		payload["response_format"] = map[string]string{"type": "json_object"}
+	body, err := j.send(ctx, messages)
+	if err != nil {
+		return LLMResult{}, err
 	}
 
-	jsonData, err := json.Marshal(payload)
+	reply, err := j.api.parseResponse(body)
 	if err != nil {
-		return LLMResult{}, fmt.Errorf("failed to marshal request: %w", err)
+		return LLMResult{}, err
 	}
+	content := strings.TrimSpace(reply.text)
 
-	req, err := http.NewRequestWithContext(ctx, "POST", j.endpoint, bytes.NewBuffer(jsonData))
+	var result LLMResult
+	switch {
+	case j.outputFormat == LLMSimple && j.hardened:
+		result, err = parseBoundVerdict(content, nonce)
+	case j.outputFormat == LLMSimple:
+		result, err = parseSimpleResponse(content)
+	case j.hardened:
+		if err = checkStructuredNonce(content, nonce); err == nil {
+			result, err = parseStructuredResponse(content)
+		}
+	default:
+		result, err = parseStructuredResponse(content)
+	}
+	if err == nil && j.outputFormat == LLMSimple && j.logprobs {
+		marker := ""
+		if j.hardened {
+			marker = verdictMarker(nonce)
+		}
+		if isAttack, confidence, ok := logprobVerdict(reply.logprobs, marker); ok {
+			result.IsAttack = isAttack
+			result.Confidence = confidence
+		}
+	}
 	if err != nil {
-		return LLMResult{}, fmt.Errorf("failed to create request: %w", err)
+		// The tokens were spent even though the reply was unusable
+		return LLMResult{Usage: reply.usage}, err
 	}
+	result.Usage = reply.usage
+	return result, nil
+}
 
-	req.Header.Set("Content-Type", "application/json")
-	if j.apiKey != "" {
-		req.Header.Set("Authorization", "Bearer "+j.apiKey)
+// do applies the static headers and request hooks, then sends req.
+func (j *GenericLLMJudge) do(req *http.Request) (*http.Response, error) {
+	return doRequest(j.httpClient, j.headers, j.hooks, req)
+}
+
+// doRequest sets headers on req, runs hooks on it, then sends it with client.
+func doRequest(client *http.Client, headers map[string]string, hooks []func(*http.Request) error, req *http.Request) (*http.Response, error) {
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	for _, hook := range hooks {
+		if err := hook(req); err != nil {
+			return nil, fmt.Errorf("request hook failed: %w", err)
+		}
+	}
+	return client.Do(req)
+}
+
+// userPrompt is the user turn asking for a verdict on input.
+func (j *GenericLLMJudge) userPrompt(input, nonce string) string {
+	if j.hardened {
+		return buildHardenedUserPrompt(input, nonce, j.marking, j.outputFormat)
 	}
+	return buildUserPrompt(input)
+}
+
+// chatMessage is one conversation turn. The system prompt is passed separately
+// because providers place it differently (a message, a top-level field, ...).
+type chatMessage struct {
+	Role    string `json:"role"` // "user" or "assistant"
+	Content string `json:"content"`
+}
 
-	resp, err := j.httpClient.Do(req)
+// chatAPI adapts the judge to one provider's request and response shape.
+// Prompts, options and verdict parsing stay shared in GenericLLMJudge.
+type chatAPI interface {
+	newRequest(ctx context.Context, j *GenericLLMJudge, messages []chatMessage) (*http.Request, error)
+	// parseResponse extracts the generated text and whatever metadata the provider sent.
+	parseResponse(body []byte) (chatReply, error)
+}
+
+// chatReply is a provider response reduced to what the judge uses.
+type chatReply struct {
+	text     string
+	usage    TokenUsage
+	logprobs []tokenLogprob // per generated token, when requested and supported
+}
+
+// openAIChat is the OpenAI chat-completions format, also served by OpenRouter, Ollama and Azure.
+type openAIChat struct {
+	// keyHeader sends the API key raw in this header instead of "Authorization: Bearer".
+	keyHeader string
+}
+
+func (a openAIChat) newRequest(ctx context.Context, j *GenericLLMJudge, messages []chatMessage) (*http.Request, error) {
+	payload := map[string]interface{}{
+		"model":       j.model,
+		"messages":    append([]chatMessage{{Role: "system", Content: j.systemPrompt}}, messages...),
+		"temperature": 1,
+	}
+	if j.temperature != nil {
+		payload["temperature"] = *j.temperature
+	}
+
+	if j.outputFormat == LLMStructured && j.jsonSchema {
+		payload["response_format"] = map[string]interface{}{
+			"type": "json_schema",
+			"json_schema": map[string]interface{}{
+				"name":   "prompt_injection_verdict",
+				"strict": true,
+				"schema": verdictSchema(j.hardened),
+			},
+		}
+	} else if j.outputFormat == LLMStructured {
+		payload["response_format"] = map[string]string{"type": "json_object"}
+	} else if j.logprobs {
+		payload["logprobs"] = true
+		payload["top_logprobs"] = logprobAlternatives
+	}
+
+	req, err := newJSONRequest(ctx, j.endpoint, payload)
 	if err != nil {
-		return LLMResult{}, fmt.Errorf("failed to execute request: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return LLMResult{}, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+	if j.apiKey != "" {
+		if a.keyHeader != "" {
+			req.Header.Set(a.keyHeader, j.apiKey)
+		} else {
+			req.Header.Set("Authorization", "Bearer "+j.apiKey)
+		}
 	}
+	return req, nil
+}
 
-	// Parse response
+func (a openAIChat) parseResponse(body []byte) (chatReply, error) {
 	var apiResp struct {
 		Choices []struct {
 			Message struct {
 				Content string `json:"content"`
 			} `json:"message"`
+			Logprobs struct {
+				Content []tokenLogprob `json:"content"`
+			} `json:"logprobs"`
 		} `json:"choices"`
+		Usage struct {
+			PromptTokens     int `json:"prompt_tokens"`
+			CompletionTokens int `json:"completion_tokens"`
+			TotalTokens      int `json:"total_tokens"`
+		} `json:"usage"`
 	}
 
-	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
-		return LLMResult{}, fmt.Errorf("failed to decode response: %w", err)
+	if err := json.Unmarshal(body, &apiResp); err != nil {
+		return chatReply{}, fmt.Errorf("failed to decode response: %w", err)
 	}
 
 	if len(apiResp.Choices) == 0 {
-		return LLMResult{}, fmt.Errorf("no response from LLM")
+		return chatReply{}, fmt.Errorf("no response from LLM")
+	}
+
+	usage := TokenUsage{
+		PromptTokens:     apiResp.Usage.PromptTokens,
+		CompletionTokens: apiResp.Usage.CompletionTokens,
+		TotalTokens:      apiResp.Usage.TotalTokens,
+	}
+	return chatReply{
+		text:     apiResp.Choices[0].Message.Content,
+		usage:    usage,
+		logprobs: apiResp.Choices[0].Logprobs.Content,
+	}, nil
+}
+
+func newJSONRequest(ctx context.Context, endpoint string, payload any) (*http.Request, error) {
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
+
+var (
+	// ErrUnboundVerdict means the reply did not echo the request nonce, so it may
+	// have been written by the input rather than the judge.
+	ErrUnboundVerdict = errors.New("LLM verdict is not bound to the request nonce")
+
+	// ErrAmbiguousVerdict means the reply names both SAFE and ATTACK.
+	ErrAmbiguousVerdict = errors.New("LLM response mentions both SAFE and ATTACK")
+)
+
+var labelPattern = regexp.MustCompile(`(?i)\b(SAFE|ATTACK)\b`)
+
+// parseBoundVerdict parses a hardened simple reply: "VERDICT-<nonce>: SAFE|ATTACK".
+func parseBoundVerdict(content, nonce string) (LLMResult, error) {
+	marker := regexp.MustCompile(`(?i)` + regexp.QuoteMeta(verdictMarker(nonce)))
+	loc := marker.FindStringIndex(content)
+	if loc == nil {
+		return LLMResult{}, fmt.Errorf("%w: %s", ErrUnboundVerdict, content)
 	}
 
-	content := strings.TrimSpace(apiResp.Choices[0].Message.Content)
+	labels := make(map[string]bool)
+	for _, m := range labelPattern.FindAllString(content, -1) {
+		labels[strings.ToUpper(m)] = true
+	}
+	if len(labels) > 1 {
+		return LLMResult{}, fmt.Errorf("%w: %s", ErrAmbiguousVerdict, content)
+	}
 
-	if j.outputFormat == LLMSimple {
-		return parseSimpleResponse(content)
+	fields := strings.Fields(content[loc[1]:])
+	if len(fields) == 0 {
+		return LLMResult{}, fmt.Errorf("unexpected response: %s", content)
+	}
+	switch strings.ToUpper(strings.Trim(fields[0], `."'*`)) {
+	case "ATTACK":
+		return LLMResult{IsAttack: true, Confidence: 0.9}, nil
+	case "SAFE":
+		return LLMResult{IsAttack: false, Confidence: 0.9}, nil
+	}
+	return LLMResult{}, fmt.Errorf("unexpected response: %s", content)
+}
+
+// checkStructuredNonce verifies a hardened JSON reply carries the request nonce.
+func checkStructuredNonce(content, nonce string) error {
+	var resp struct {
+		Nonce string `json:"nonce"`
+	}
+	if err := json.Unmarshal([]byte(extractJSON(content)), &resp); err != nil {
+		return fmt.Errorf("failed to parse JSON response: %w", err)
+	}
+	if !strings.EqualFold(resp.Nonce, nonce) {
+		return fmt.Errorf("%w: %s", ErrUnboundVerdict, content)
 	}
-	return parseStructuredResponse(content)
+	return nil
 }
 
 // parseSimpleResponse parses "SAFE" or "ATTACK" responses.
@@ -161,20 +435,76 @@ func parseSimpleResponse(content string) (LLMResult, error) {
 
 func parseStructuredResponse(content string) (LLMResult, error) {
 	var resp struct {
-		IsAttack   bool    `json:"is_attack"`
+		IsAttack   *bool   `json:"is_attack"`
 		Confidence float64 `json:"confidence"`
 		AttackType string  `json:"attack_type"`
 		Reasoning  string  `json:"reasoning"`
 	}
 
-	if err := json.Unmarshal([]byte(content), &resp); err != nil {
+	if err := json.Unmarshal([]byte(extractJSON(content)), &resp); err != nil {
 		return LLMResult{}, fmt.Errorf("failed to parse JSON response: %w", err)
 	}
+	// A reply without a verdict must not pass as SAFE
+	if resp.IsAttack == nil {
+		return LLMResult{}, errors.New("JSON response has no is_attack field")
+	}
+
+	confidence := resp.Confidence
+	if confidence < 0 {
+		confidence = 0
+	} else if confidence > 1 {
+		confidence = 1
+	}
 
 	return LLMResult{
-		IsAttack:   resp.IsAttack,
-		Confidence: resp.Confidence,
-		AttackType: resp.AttackType,
+		IsAttack:   *resp.IsAttack,
+		Confidence: confidence,
+		AttackType: normalizeAttackType(resp.AttackType),
 		Reasoning:  resp.Reasoning,
 	}, nil
 }
+
+var (
+	thinkBlock = regexp.MustCompile(`(?is)<think(?:ing)?>.*?</think(?:ing)?>`)
+	codeFence  = regexp.MustCompile("(?s)```(?:json|JSON)?\\s*(.*?)```")
+)
+
+// extractJSON finds the JSON object in a model reply that may open with
+// <think> reasoning, wrap the object in a markdown fence or add prose around it.
+// Returns content unchanged when no object is found, so the decode error shows it.
+func extractJSON(content string) string {
+	text := thinkBlock.ReplaceAllString(content, "")
+	// Some models leave the opening tag in the prompt template and only close it
+	if i := strings.LastIndex(strings.ToLower(text), "</think>"); i >= 0 {
+		text = text[i+len("</think>"):]
+	}
+	if m := codeFence.FindStringSubmatch(text); m != nil {
+		text = m[1]
+	}
+
+	start := strings.Index(text, "{")
+	if start < 0 {
+		return content
+	}
+	depth, inString, escaped := 0, false, false
+	for i := start; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case escaped:
+			escaped = false
+		case inString && c == '\\':
+			escaped = true
+		case c == '"':
+			inString = !inString
+		case inString:
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+			if depth == 0 {
+				return text[start : i+1]
+			}
+		}
+	}
+	return content
+}
@@ -6,7 +6,7 @@ import "context"
 type LLMJudge interface {
 	Judge(ctx context.Context, input string) (LLMResult, error)
 	// Warmup pre-loads the model to avoid cold start latency on the first real call.
-	// No-op for remote APIs (OpenAI, OpenRouter). Useful for local models (Ollama).
+	// No-op for remote APIs (OpenAI, OpenRouter, Anthropic). Useful for local models (Ollama).
 	Warmup(ctx context.Context)
 }
 
@@ -15,6 +15,64 @@ type LLMResult struct {
 	Confidence float64 // 0.0-1.0 confidence score
 	Reasoning  string  // Optional explanation (depends on output format)
 	AttackType string  // Optional attack classification
+	DecidedBy  string  // Optional: stage that produced the verdict (CascadeJudge)
+	Usage      TokenUsage
+}
+
+// TokenUsage is what the provider reported for one call. Zero when it reported nothing.
+type TokenUsage struct {
+	PromptTokens     int
+	CompletionTokens int
+	TotalTokens      int
+}
+
+func (u TokenUsage) add(o TokenUsage) TokenUsage {
+	return TokenUsage{
+		PromptTokens:     u.PromptTokens + o.PromptTokens,
+		CompletionTokens: u.CompletionTokens + o.CompletionTokens,
+		TotalTokens:      u.TotalTokens + o.TotalTokens,
+	}
+}
+
+// split divides u into n parts that add up to u, for requests that served n inputs.
+func (u TokenUsage) split(n int) []TokenUsage {
+	parts := make([]TokenUsage, n)
+	share := func(total, i int) int {
+		s := total / n
+		if i < total%n {
+			s++
+		}
+		return s
+	}
+	for i := range parts {
+		parts[i] = TokenUsage{
+			PromptTokens:     share(u.PromptTokens, i),
+			CompletionTokens: share(u.CompletionTokens, i),
+			TotalTokens:      share(u.TotalTokens, i),
+		}
+	}
+	return parts
+}
+
+// PatternVerdict is the pattern-based result MultiDetector had before calling the judge.
+type PatternVerdict struct {
+	RiskScore float64 // weighted score before the LLM ran
+	Unsafe    bool    // RiskScore >= threshold
+}
+
+type patternVerdictKey struct{}
+
+// WithPatternVerdict attaches the pattern-based verdict to ctx for the judge.
+// MultiDetector does this before every judge call.
+func WithPatternVerdict(ctx context.Context, v PatternVerdict) context.Context {
+	return context.WithValue(ctx, patternVerdictKey{}, v)
+}
+
+// PatternVerdictFromContext returns the pattern-based verdict, if the judge is
+// being called by MultiDetector. Judges can use it to compare against the patterns.
+func PatternVerdictFromContext(ctx context.Context) (PatternVerdict, bool) {
+	v, ok := ctx.Value(patternVerdictKey{}).(PatternVerdict)
+	return v, ok
 }
 
 type LLMRunMode int
@@ -23,11 +81,36 @@ const (
 	// LLMAlways runs the LLM on every input (most accurate, most expensive).
 	LLMAlways LLMRunMode = iota
 
-	// LLMConditional runs the LLM only when pattern-based detectors are uncertain (0.5-0.7 score).
+	// LLMConditional runs the LLM only when pattern-based detectors are uncertain
+	// (score within the band set by WithLLMBand, default 0.5-0.7).
 	LLMConditional
 
 	// LLMFallback runs the LLM only when pattern-based detectors say safe (double-check negatives).
 	LLMFallback
+
+	// LLMVerify runs the LLM only when pattern-based detectors say unsafe, and clears
+	// their detections when the LLM is confident the input is benign (rescue false positives).
+	LLMVerify
+
+	// LLMShadow returns the pattern-based result immediately and judges a sample of
+	// inputs in the background, reporting disagreements (see WithShadowHandler).
+	// The LLM never changes the returned verdict.
+	LLMShadow
+)
+
+// InputMarking controls how GenericLLMJudge presents untrusted input to the model.
+type InputMarking int
+
+const (
+	// InputDatamark replaces whitespace in the input with a marker character so the
+	// model can tell input text from instructions (default).
+	InputDatamark InputMarking = iota
+
+	// InputBase64 sends the input base64-encoded. Strongest separation, needs a capable model.
+	InputBase64
+
+	// InputRaw sends the input unchanged (still inside nonce delimiters).
+	InputRaw
 )
 
 type LLMOutputFormat int
@@ -0,0 +1,87 @@
+package detector
+
+import (
+	"math"
+	"strings"
+)
+
+// logprobAlternatives is how many candidate tokens to request per position.
+const logprobAlternatives = 5
+
+// tokenLogprob is one generated token in the OpenAI logprobs format.
+type tokenLogprob struct {
+	Token       string  `json:"token"`
+	Logprob     float64 `json:"logprob"`
+	TopLogprobs []struct {
+		Token   string  `json:"token"`
+		Logprob float64 `json:"logprob"`
+	} `json:"top_logprobs"`
+}
+
+// logprobVerdict reads the first non-blank generated token after marker (the
+// "VERDICT-<nonce>:" prefix of hardened prompts, or "" for none), which decides
+// SAFE or ATTACK, and sums the probability of the alternatives that start each label.
+// Tokenizers may split labels ("ATT" + "ACK"), so any label prefix counts.
+// Returns false when neither label appears among the alternatives.
+func logprobVerdict(tokens []tokenLogprob, marker string) (isAttack bool, confidence float64, ok bool) {
+	marker = strings.ToLower(marker)
+	var generated strings.Builder
+	for _, t := range tokens {
+		if marker != "" {
+			// Skip until the marker is complete; the token finishing it may also hold the label
+			before := strings.Contains(strings.ToLower(generated.String()), marker)
+			generated.WriteString(t.Token)
+			if !before {
+				after := strings.ToLower(generated.String())
+				i := strings.Index(after, marker)
+				if i < 0 || labelToken(after[i+len(marker):]) == "" {
+					continue
+				}
+			}
+		}
+		if labelToken(t.Token) == "" {
+			continue
+		}
+
+		var pAttack, pSafe float64
+		add := func(token string, logprob float64) {
+			switch labelToken(token) {
+			case "ATTACK":
+				pAttack += math.Exp(logprob)
+			case "SAFE":
+				pSafe += math.Exp(logprob)
+			}
+		}
+		if len(t.TopLogprobs) == 0 {
+			add(t.Token, t.Logprob)
+		}
+		for _, alt := range t.TopLogprobs {
+			add(alt.Token, alt.Logprob)
+		}
+
+		total := pAttack + pSafe
+		if total == 0 {
+			return false, 0, false
+		}
+		if pAttack > pSafe {
+			return true, round(pAttack/total, 2), true
+		}
+		return false, round(pSafe/total, 2), true
+	}
+	return false, 0, false
+}
+
+// labelToken returns the label a token starts, "" for blank tokens and "?" for anything else.
+func labelToken(token string) string {
+	t := strings.ToUpper(strings.Trim(token, " \t\n\"'*`:"))
+	switch {
+	case t == "":
+		return ""
+	case strings.HasPrefix("ATTACK", t):
+		return "ATTACK"
+	case strings.HasPrefix("SAFE", t):
+		return "SAFE"
+	default:
+		return "?"
+	}
+}
@@ -0,0 +1,139 @@
+package detector
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogprobVerdict(t *testing.T) {
+	lp := math.Log
+
+	tests := []struct {
+		name       string
+		json       string
+		marker     string
+		ok         bool
+		isAttack   bool
+		confidence float64
+	}{
+		{
+			name: "confident attack",
+			json: `[{"token": "ATTACK", "logprob": -0.05, "top_logprobs": [
+				{"token": "ATTACK", "logprob": ` + ftoa(lp(0.95)) + `},
+				{"token": "SAFE", "logprob": ` + ftoa(lp(0.05)) + `}]}]`,
+			ok: true, isAttack: true, confidence: 0.95,
+		},
+		{
+			name: "split label tokens and noise are normalized",
+			json: `[{"token": " ", "logprob": 0}, {"token": "SAFE", "logprob": -0.5, "top_logprobs": [
+				{"token": "SAFE", "logprob": ` + ftoa(lp(0.4)) + `},
+				{"token": "ATT", "logprob": ` + ftoa(lp(0.3)) + `},
+				{"token": " Safe", "logprob": ` + ftoa(lp(0.2)) + `},
+				{"token": "I", "logprob": ` + ftoa(lp(0.1)) + `}]}]`,
+			ok: true, isAttack: false, confidence: round(0.6/0.9, 2),
+		},
+		{
+			name: "verdict follows the more likely label",
+			json: `[{"token": "SAFE", "logprob": -1.2, "top_logprobs": [
+				{"token": "ATTACK", "logprob": ` + ftoa(lp(0.7)) + `},
+				{"token": "SAFE", "logprob": ` + ftoa(lp(0.3)) + `}]}]`,
+			ok: true, isAttack: true, confidence: 0.7,
+		},
+		{
+			name: "no alternatives uses the token itself",
+			json: `[{"token": "SAFE", "logprob": -0.1}]`,
+			ok:   true, isAttack: false, confidence: 1,
+		},
+		{
+			name: "no label",
+			json: `[{"token": "Sorry", "logprob": -0.1, "top_logprobs": [{"token": "I", "logprob": -2}]}]`,
+		},
+		{name: "no logprobs", json: `[]`},
+		{
+			name: "label after the verdict marker",
+			json: `[{"token": "VER", "logprob": 0}, {"token": "DICT-ab", "logprob": 0}, {"token": "12:", "logprob": 0},
+				{"token": " SAFE", "logprob": -0.2, "top_logprobs": [
+					{"token": " SAFE", "logprob": ` + ftoa(lp(0.8)) + `},
+					{"token": " ATTACK", "logprob": ` + ftoa(lp(0.2)) + `}]}]`,
+			marker: "VERDICT-ab12:",
+			ok:     true, isAttack: false, confidence: 0.8,
+		},
+		{
+			name: "label in the token that completes the marker",
+			json: `[{"token": "VERDICT-ab12", "logprob": 0}, {"token": ": ATTACK", "logprob": -0.1, "top_logprobs": [
+					{"token": ": ATTACK", "logprob": ` + ftoa(lp(0.9)) + `},
+					{"token": ": SAFE", "logprob": ` + ftoa(lp(0.1)) + `}]}]`,
+			marker: "VERDICT-ab12:",
+			ok:     true, isAttack: true, confidence: 0.9,
+		},
+		{
+			name:   "marker never generated",
+			json:   `[{"token": "SAFE", "logprob": -0.1}]`,
+			marker: "VERDICT-ab12:",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tokens []tokenLogprob
+			require.NoError(t, json.Unmarshal([]byte(tt.json), &tokens))
+
+			isAttack, confidence, ok := logprobVerdict(tokens, tt.marker)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.isAttack, isAttack)
+			assert.Equal(t, tt.confidence, confidence)
+		})
+	}
+}
+
+func ftoa(f float64) string {
+	b, _ := json.Marshal(f)
+	return string(b)
+}
+
+func TestGenericLLMJudge_LogprobConfidence(t *testing.T) {
+	var req map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reply := withRequestNonce(t, r, `{"choices": [{"message": {"content": "VERDICT-{nonce}: ATTACK"}, "logprobs": {"content": [
+			{"token": "VERDICT-{nonce}:", "logprob": 0},
+			{"token": " ATTACK", "logprob": -0.4, "top_logprobs": [
+				{"token": " ATTACK", "logprob": `+ftoa(math.Log(0.6))+`},
+				{"token": " SAFE", "logprob": `+ftoa(math.Log(0.4))+`}]}]}}]}`)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		w.Write([]byte(reply))
+	}))
+	defer srv.Close()
+
+	judge := NewGenericLLMJudge(srv.URL, "", "test-model", WithLogprobConfidence(), WithTemperature(0))
+	result, err := judge.Judge(context.Background(), "input")
+	require.NoError(t, err)
+	assert.True(t, result.IsAttack)
+	assert.Equal(t, 0.6, result.Confidence)
+
+	assert.Equal(t, true, req["logprobs"])
+	assert.Equal(t, float64(logprobAlternatives), req["top_logprobs"])
+	assert.Equal(t, float64(0), req["temperature"])
+}
+
+func TestGenericLLMJudge_DefaultTemperatureAndConfidence(t *testing.T) {
+	var req map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reply := withRequestNonce(t, r, `{"choices": [{"message": {"content": "VERDICT-{nonce}: SAFE"}}]}`)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		w.Write([]byte(reply))
+	}))
+	defer srv.Close()
+
+	result, err := NewGenericLLMJudge(srv.URL, "", "test-model").Judge(context.Background(), "input")
+	require.NoError(t, err)
+	assert.Equal(t, 0.9, result.Confidence)
+	assert.Equal(t, float64(1), req["temperature"])
+	assert.NotContains(t, req, "logprobs")
+}
@@ -0,0 +1,288 @@
+package detector
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrOllamaModelNotFound is returned by NewOllamaNativeJudge when the model is not pulled.
+var ErrOllamaModelNotFound = errors.New("ollama model not found")
+
+// OllamaStats is cumulative timing and token counts reported by Ollama.
+type OllamaStats struct {
+	Calls            int           // responses received, including Warmup
+	LastLoadDuration time.Duration // time the last call spent loading the model
+	LoadDuration     time.Duration // total time spent loading the model
+	PromptEvalCount  int           // prompt tokens evaluated
+	EvalCount        int           // tokens generated
+	EvalDuration     time.Duration // time spent generating
+	TotalDuration    time.Duration // total time Ollama spent on the calls
+}
+
+// OllamaJudge is a GenericLLMJudge speaking Ollama's native /api/chat, which
+// unlike the OpenAI-compatible endpoint can keep the model loaded, size its
+// context window and force JSON output.
+type OllamaJudge struct {
+	*GenericLLMJudge
+	baseURL string
+	api     *ollamaChat
+}
+
+// NewOllamaNativeJudge creates a judge for Ollama's native API and checks via
+// /api/tags that the model is pulled. An empty endpoint means http://localhost:11434.
+// Default timeout: 60s, like NewOllamaJudge.
+//
+// Example:
+//
+//	judge, err := detector.NewOllamaNativeJudge(ctx, "", "llama3.1:8b",
+//	    detector.WithKeepAlive(30*time.Minute),
+//	    detector.WithNumCtx(8192),
+//	)
+//	if err != nil {
+//	    log.Fatal(err) // e.g. ollama model "llama3.1:8b" not found ... run: ollama pull llama3.1:8b
+//	}
+//	go judge.Warmup(ctx)
+func NewOllamaNativeJudge(ctx context.Context, endpoint, model string, opts ...LLMJudgeOption) (*OllamaJudge, error) {
+	if endpoint == "" {
+		endpoint = "http://localhost:11434"
+	}
+	endpoint = strings.TrimSuffix(endpoint, "/")
+
+	api := &ollamaChat{}
+	j := newLLMJudge(api, endpoint+"/api/chat", "", model,
+		withDefaults([]LLMJudgeOption{WithLLMTimeout(60 * time.Second)}, opts)...)
+
+	judge := &OllamaJudge{GenericLLMJudge: j, baseURL: endpoint, api: api}
+	if err := judge.checkModel(ctx); err != nil {
+		return nil, err
+	}
+	return judge, nil
+}
+
+// WithKeepAlive sets how long Ollama keeps the model loaded after a call
+// (negative: forever). Default: Ollama's own (5 minutes). Native Ollama judge only.
+func WithKeepAlive(d time.Duration) LLMJudgeOption {
+	return func(j *GenericLLMJudge) {
+		if api, ok := j.api.(*ollamaChat); ok {
+			api.keepAlive = &d
+		}
+	}
+}
+
+// WithNumCtx sets the model's context window in tokens. Default: the model's own.
+// Native Ollama judge only.
+func WithNumCtx(tokens int) LLMJudgeOption {
+	return func(j *GenericLLMJudge) {
+		if api, ok := j.api.(*ollamaChat); ok && tokens > 0 {
+			api.numCtx = tokens
+		}
+	}
+}
+
+// CacheIdentity adds the num_ctx and keep_alive settings to the generic identity.
+func (o *OllamaJudge) CacheIdentity() string {
+	id := o.GenericLLMJudge.CacheIdentity()
+	if o.api.numCtx > 0 {
+		id += fmt.Sprintf("\x00num_ctx=%d", o.api.numCtx)
+	}
+	if o.api.keepAlive != nil {
+		id += fmt.Sprintf("\x00keep_alive=%s", *o.api.keepAlive)
+	}
+	return id
+}
+
+// Warmup loads the model into memory without classifying anything, using
+// the keep_alive setting so it stays resident.
+func (o *OllamaJudge) Warmup(ctx context.Context) {
+	payload := map[string]interface{}{
+		"model":    o.model,
+		"messages": []chatMessage{},
+		"stream":   false,
+	}
+	o.api.setKeepAlive(payload)
+
+	req, err := newJSONRequest(ctx, o.endpoint, payload)
+	if err != nil {
+		return
+	}
+	resp, err := o.do(req)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return
+	}
+	var timing ollamaTiming
+	if json.Unmarshal(body, &timing) == nil {
+		o.api.record(timing)
+	}
+}
+
+// Stats returns cumulative load time and eval counts.
+func (o *OllamaJudge) Stats() OllamaStats {
+	o.api.mu.Lock()
+	defer o.api.mu.Unlock()
+	return o.api.stats
+}
+
+// checkModel fails with ErrOllamaModelNotFound unless /api/tags lists the model.
+func (o *OllamaJudge) checkModel(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", o.baseURL+"/api/tags", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := o.do(req)
+	if err != nil {
+		return fmt.Errorf("cannot reach Ollama at %s: %w", o.baseURL, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	var tags struct {
+		Models []struct {
+			Name  string `json:"name"`
+			Model string `json:"model"`
+		} `json:"models"`
+	}
+	if err := json.Unmarshal(body, &tags); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	available := make([]string, 0, len(tags.Models))
+	for _, m := range tags.Models {
+		if sameOllamaModel(m.Name, o.model) || sameOllamaModel(m.Model, o.model) {
+			return nil
+		}
+		available = append(available, m.Name)
+	}
+	return fmt.Errorf("%w: %q at %s (available: %s); run: ollama pull %s",
+		ErrOllamaModelNotFound, o.model, o.baseURL, strings.Join(available, ", "), o.model)
+}
+
+// sameOllamaModel compares model names, treating a missing tag as ":latest".
+func sameOllamaModel(a, b string) bool {
+	withTag := func(name string) string {
+		if !strings.Contains(name, ":") {
+			return name + ":latest"
+		}
+		return name
+	}
+	return withTag(a) == withTag(b)
+}
+
+// ollamaTiming is the timing and token count block of an /api/chat response (nanoseconds).
+type ollamaTiming struct {
+	TotalDuration   int64 `json:"total_duration"`
+	LoadDuration    int64 `json:"load_duration"`
+	PromptEvalCount int   `json:"prompt_eval_count"`
+	EvalCount       int   `json:"eval_count"`
+	EvalDuration    int64 `json:"eval_duration"`
+}
+
+// ollamaChat is Ollama's native /api/chat format.
+type ollamaChat struct {
+	keepAlive *time.Duration
+	numCtx    int
+
+	mu    sync.Mutex
+	stats OllamaStats
+}
+
+func (a *ollamaChat) newRequest(ctx context.Context, j *GenericLLMJudge, messages []chatMessage) (*http.Request, error) {
+	payload := map[string]interface{}{
+		"model":    j.model,
+		"messages": append([]chatMessage{{Role: "system", Content: j.systemPrompt}}, messages...),
+		"stream":   false,
+	}
+	a.setKeepAlive(payload)
+
+	options := map[string]interface{}{}
+	if a.numCtx > 0 {
+		options["num_ctx"] = a.numCtx
+	}
+	if j.temperature != nil {
+		options["temperature"] = *j.temperature
+	}
+	if len(options) > 0 {
+		payload["options"] = options
+	}
+
+	if j.outputFormat == LLMStructured {
+		if j.jsonSchema {
+			payload["format"] = verdictSchema(j.hardened)
+		} else {
+			payload["format"] = "json"
+		}
+	}
+
+	return newJSONRequest(ctx, j.endpoint, payload)
+}
+
+func (a *ollamaChat) setKeepAlive(payload map[string]interface{}) {
+	if a.keepAlive == nil {
+		return
+	}
+	if *a.keepAlive < 0 {
+		payload["keep_alive"] = -1
+	} else {
+		payload["keep_alive"] = a.keepAlive.String()
+	}
+}
+
+func (a *ollamaChat) parseResponse(body []byte) (chatReply, error) {
+	var apiResp struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+		Error string `json:"error"`
+		ollamaTiming
+	}
+
+	if err := json.Unmarshal(body, &apiResp); err != nil {
+		return chatReply{}, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if apiResp.Error != "" {
+		return chatReply{}, fmt.Errorf("ollama error: %s", apiResp.Error)
+	}
+	a.record(apiResp.ollamaTiming)
+
+	if apiResp.Message.Content == "" {
+		return chatReply{}, fmt.Errorf("no response from LLM")
+	}
+
+	usage := TokenUsage{
+		PromptTokens:     apiResp.PromptEvalCount,
+		CompletionTokens: apiResp.EvalCount,
+		TotalTokens:      apiResp.PromptEvalCount + apiResp.EvalCount,
+	}
+	return chatReply{text: apiResp.Message.Content, usage: usage}, nil
+}
+
+func (a *ollamaChat) record(t ollamaTiming) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.stats.Calls++
+	a.stats.LastLoadDuration = time.Duration(t.LoadDuration)
+	a.stats.LoadDuration += time.Duration(t.LoadDuration)
+	a.stats.PromptEvalCount += t.PromptEvalCount
+	a.stats.EvalCount += t.EvalCount
+	a.stats.EvalDuration += time.Duration(t.EvalDuration)
+	a.stats.TotalDuration += time.Duration(t.TotalDuration)
+}
@@ -0,0 +1,212 @@
+package detector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without calling the provider while the judge's circuit breaker is open.
+var ErrCircuitOpen = errors.New("LLM circuit breaker is open")
+
+// APIError is a non-200 response from an LLM provider.
+type APIError struct {
+	StatusCode int
+	Body       string
+	RetryAfter time.Duration // parsed Retry-After header, 0 if absent
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API returned status %d: %s", e.StatusCode, e.Body)
+}
+
+// Retryable reports whether the request may succeed if sent again (rate limits and server errors).
+func (e *APIError) Retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// retryPolicy controls how GenericLLMJudge retries failed requests.
+type retryPolicy struct {
+	maxRetries int
+	baseDelay  time.Duration
+	maxDelay   time.Duration
+}
+
+// backoff returns the wait before retry number attempt (0-based): exponential
+// growth from baseDelay with equal jitter, at least Retry-After, capped at maxDelay.
+func (p retryPolicy) backoff(attempt int, retryAfter time.Duration) time.Duration {
+	d := p.baseDelay << attempt
+	if d <= 0 || d > p.maxDelay {
+		d = p.maxDelay
+	}
+	d = d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+
+	if retryAfter > d {
+		d = retryAfter
+	}
+	if d > p.maxDelay {
+		d = p.maxDelay
+	}
+	return d
+}
+
+// send executes one provider call with retries and the circuit breaker,
+// returning the body of the first 200 response.
+func (j *GenericLLMJudge) send(ctx context.Context, messages []chatMessage) ([]byte, error) {
+	if j.breaker != nil && !j.breaker.allow() {
+		return nil, ErrCircuitOpen
+	}
+
+	var lastErr error
+	for attempt := 0; ; attempt++ {
+		body, err := j.attempt(ctx, messages)
+		if err == nil {
+			if j.breaker != nil {
+				j.breaker.success()
+			}
+			return body, nil
+		}
+		lastErr = err
+
+		var apiErr *APIError
+		isAPIErr := errors.As(err, &apiErr)
+		retryable := ctx.Err() == nil && (!isAPIErr || apiErr.Retryable())
+		if !retryable || attempt >= j.retry.maxRetries {
+			break
+		}
+
+		var retryAfter time.Duration
+		if isAPIErr {
+			retryAfter = apiErr.RetryAfter
+		}
+		timer := time.NewTimer(j.retry.backoff(attempt, retryAfter))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			j.settleBreaker(ctx, lastErr)
+			return nil, lastErr
+		case <-timer.C:
+		}
+	}
+
+	j.settleBreaker(ctx, lastErr)
+	return nil, lastErr
+}
+
+// settleBreaker records a failed call with the circuit breaker. Client errors
+// (bad key, bad request) are replies, so they count as the provider being up.
+// A call the caller cancelled says nothing about the provider and only ends a
+// half-open trial.
+func (j *GenericLLMJudge) settleBreaker(ctx context.Context, err error) {
+	if j.breaker == nil {
+		return
+	}
+	var apiErr *APIError
+	switch {
+	case errors.Is(ctx.Err(), context.Canceled):
+		j.breaker.release()
+	case errors.As(err, &apiErr) && !apiErr.Retryable():
+		j.breaker.success()
+	default:
+		j.breaker.failure()
+	}
+}
+
+func (j *GenericLLMJudge) attempt(ctx context.Context, messages []chatMessage) ([]byte, error) {
+	req, err := j.api.newRequest(ctx, j, messages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := j.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &APIError{
+			StatusCode: resp.StatusCode,
+			Body:       string(body),
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+		}
+	}
+	return body, nil
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an HTTP date.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(value); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// circuitBreaker opens after threshold consecutive failed calls and rejects calls
+// until cooldown passes. Then one trial call is let through: success closes the
+// breaker, failure opens it for another cooldown.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openUntil time.Time
+	trial     bool // a half-open trial call is in flight
+}
+
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.threshold {
+		return true
+	}
+	if time.Now().Before(b.openUntil) || b.trial {
+		return false
+	}
+	b.trial = true
+	return true
+}
+
+func (b *circuitBreaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = 0
+	b.trial = false
+}
+
+// release ends a half-open trial without a verdict, so the next call becomes the trial.
+func (b *circuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+}
+
+func (b *circuitBreaker) failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	b.trial = false
+	if b.failures >= b.threshold {
+		b.openUntil = time.Now().Add(b.cooldown)
+	}
+}
@@ -0,0 +1,218 @@
+package detector
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyServer fails the first failures requests with status, then answers ATTACK.
+func flakyServer(t *testing.T, failures int32, status int, header http.Header) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= failures {
+			for k, v := range header {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(status)
+			w.Write([]byte(`{"error": "try again"}`))
+			return
+		}
+		w.Write([]byte(withRequestNonce(t, r, `{"choices": [{"message": {"content": "VERDICT-{nonce}: ATTACK"}}]}`)))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func fastRetries(n int) LLMJudgeOption {
+	return func(j *GenericLLMJudge) {
+		WithRetries(n)(j)
+		WithRetryBackoff(time.Millisecond, 5*time.Millisecond)(j)
+	}
+}
+
+func TestGenericLLMJudge_RetriesServerErrors(t *testing.T) {
+	srv, calls := flakyServer(t, 2, http.StatusServiceUnavailable, nil)
+	judge := NewGenericLLMJudge(srv.URL, "key", "model", fastRetries(2))
+
+	result, err := judge.Judge(context.Background(), "input")
+	require.NoError(t, err)
+	assert.True(t, result.IsAttack)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestGenericLLMJudge_RetryLimit(t *testing.T) {
+	srv, calls := flakyServer(t, 10, http.StatusTooManyRequests, http.Header{"Retry-After": {"1"}})
+	judge := NewGenericLLMJudge(srv.URL, "key", "model", fastRetries(1))
+
+	start := time.Now()
+	_, err := judge.Judge(context.Background(), "input")
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), 500*time.Millisecond, "Retry-After is capped at the max backoff")
+
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusTooManyRequests, apiErr.StatusCode)
+	assert.Equal(t, time.Second, apiErr.RetryAfter)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestGenericLLMJudge_NoRetryOnClientError(t *testing.T) {
+	srv, calls := flakyServer(t, 10, http.StatusUnauthorized, nil)
+	judge := NewGenericLLMJudge(srv.URL, "key", "model", fastRetries(3))
+
+	_, err := judge.Judge(context.Background(), "input")
+	require.Error(t, err)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestGenericLLMJudge_CircuitBreaker(t *testing.T) {
+	srv, calls := flakyServer(t, 2, http.StatusInternalServerError, nil)
+	judge := NewGenericLLMJudge(srv.URL, "key", "model",
+		WithRetries(0),
+		WithCircuitBreaker(2, 50*time.Millisecond),
+	)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		_, err := judge.Judge(ctx, "input")
+		require.Error(t, err)
+	}
+
+	_, err := judge.Judge(ctx, "input")
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, int32(2), calls.Load(), "open breaker does not call the provider")
+
+	time.Sleep(60 * time.Millisecond)
+	result, err := judge.Judge(ctx, "input")
+	require.NoError(t, err, "trial call after cooldown closes the breaker")
+	assert.True(t, result.IsAttack)
+
+	_, err = judge.Judge(ctx, "input")
+	assert.NoError(t, err)
+}
+
+// statusServer answers each request with the next status in statuses, then with ATTACK.
+func statusServer(t *testing.T, statuses ...int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if n := int(calls.Add(1)); n <= len(statuses) {
+			w.WriteHeader(statuses[n-1])
+			w.Write([]byte(`{"error": "nope"}`))
+			return
+		}
+		w.Write([]byte(withRequestNonce(t, r, `{"choices": [{"message": {"content": "VERDICT-{nonce}: ATTACK"}}]}`)))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func TestGenericLLMJudge_CircuitBreakerTrialClientError(t *testing.T) {
+	srv, calls := statusServer(t, http.StatusInternalServerError, http.StatusInternalServerError, http.StatusBadRequest)
+	judge := NewGenericLLMJudge(srv.URL, "key", "model",
+		WithRetries(0),
+		WithCircuitBreaker(2, 50*time.Millisecond),
+	)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		_, err := judge.Judge(ctx, "input")
+		require.Error(t, err)
+	}
+	time.Sleep(60 * time.Millisecond)
+
+	_, err := judge.Judge(ctx, "input")
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr, "trial call gets a client error")
+	assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+
+	_, err = judge.Judge(ctx, "input")
+	require.NoError(t, err, "a client error is a reply and closes the breaker")
+	assert.Equal(t, int32(4), calls.Load())
+}
+
+func TestGenericLLMJudge_CircuitBreakerTrialCancelled(t *testing.T) {
+	srv, calls := statusServer(t, http.StatusInternalServerError, http.StatusInternalServerError)
+	judge := NewGenericLLMJudge(srv.URL, "key", "model",
+		WithRetries(0),
+		WithCircuitBreaker(2, 50*time.Millisecond),
+	)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		_, err := judge.Judge(ctx, "input")
+		require.Error(t, err)
+	}
+	time.Sleep(60 * time.Millisecond)
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err := judge.Judge(cancelled, "input")
+	require.ErrorIs(t, err, context.Canceled)
+	assert.NotErrorIs(t, err, ErrCircuitOpen)
+
+	result, err := judge.Judge(ctx, "input")
+	require.NoError(t, err, "a cancelled trial does not keep the breaker half-open")
+	assert.True(t, result.IsAttack)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestGenericLLMJudge_CircuitBreakerIgnoresCancel(t *testing.T) {
+	srv, _ := statusServer(t)
+	judge := NewGenericLLMJudge(srv.URL, "key", "model",
+		WithRetries(0),
+		WithCircuitBreaker(1, time.Minute),
+	)
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := judge.Judge(cancelled, "input")
+	require.ErrorIs(t, err, context.Canceled)
+
+	_, err = judge.Judge(context.Background(), "input")
+	assert.NoError(t, err, "the caller cancelling is not a provider failure")
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	assert.Equal(t, 3*time.Second, parseRetryAfter("3"))
+	assert.Equal(t, time.Duration(0), parseRetryAfter(""))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("soon"))
+
+	date := time.Now().Add(10 * time.Second).UTC().Format(http.TimeFormat)
+	d := parseRetryAfter(date)
+	assert.Greater(t, d, 5*time.Second)
+	assert.LessOrEqual(t, d, 10*time.Second)
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	p := retryPolicy{maxRetries: 5, baseDelay: 100 * time.Millisecond, maxDelay: time.Second}
+
+	for attempt := 0; attempt < 6; attempt++ {
+		d := p.backoff(attempt, 0)
+		full := p.baseDelay << attempt
+		if full > p.maxDelay {
+			full = p.maxDelay
+		}
+		assert.GreaterOrEqual(t, d, full/2)
+		assert.LessOrEqual(t, d, full)
+	}
+
+	assert.Equal(t, 800*time.Millisecond, p.backoff(0, 800*time.Millisecond))
+	assert.Equal(t, time.Second, p.backoff(0, time.Minute))
+}
+
+func TestAPIError_Retryable(t *testing.T) {
+	assert.True(t, (&APIError{StatusCode: 429}).Retryable())
+	assert.True(t, (&APIError{StatusCode: 502}).Retryable())
+	assert.False(t, (&APIError{StatusCode: 400}).Retryable())
+	assert.False(t, errors.Is(&APIError{StatusCode: 500}, ErrCircuitOpen))
+}
@@ -0,0 +1,58 @@
+package detector
+
+// ConfusionMatrix counts predictions against labels. Derived metrics are
+// percentages (0-100), like the benchmarks and the CLI report them.
+type ConfusionMatrix struct {
+	TP, FP, TN, FN int
+}
+
+// Add counts one prediction.
+func (c *ConfusionMatrix) Add(predictedAttack, actualAttack bool) {
+	switch {
+	case predictedAttack && actualAttack:
+		c.TP++
+	case predictedAttack && !actualAttack:
+		c.FP++
+	case !predictedAttack && !actualAttack:
+		c.TN++
+	default:
+		c.FN++
+	}
+}
+
+// Total is the number of predictions counted.
+func (c ConfusionMatrix) Total() int {
+	return c.TP + c.FP + c.TN + c.FN
+}
+
+func (c ConfusionMatrix) Precision() float64 {
+	return percent(c.TP, c.TP+c.FP)
+}
+
+func (c ConfusionMatrix) Recall() float64 {
+	return percent(c.TP, c.TP+c.FN)
+}
+
+func (c ConfusionMatrix) F1() float64 {
+	p, r := c.Precision(), c.Recall()
+	if p+r == 0 {
+		return 0
+	}
+	return 2 * (p * r) / (p + r)
+}
+
+func (c ConfusionMatrix) Accuracy() float64 {
+	return percent(c.TP+c.TN, c.Total())
+}
+
+// FPR is the share of benign inputs flagged as attacks.
+func (c ConfusionMatrix) FPR() float64 {
+	return percent(c.FP, c.FP+c.TN)
+}
+
+func percent(n, of int) float64 {
+	if of == 0 {
+		return 0
+	}
+	return float64(n) / float64(of) * 100
+}
@@ -0,0 +1,34 @@
+package detector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfusionMatrix(t *testing.T) {
+	var c ConfusionMatrix
+	for i := 0; i < 8; i++ {
+		c.Add(true, true)
+	}
+	c.Add(true, false)
+	c.Add(true, false)
+	for i := 0; i < 8; i++ {
+		c.Add(false, false)
+	}
+	c.Add(false, true)
+	c.Add(false, true)
+
+	assert.Equal(t, ConfusionMatrix{TP: 8, FP: 2, TN: 8, FN: 2}, c)
+	assert.Equal(t, 20, c.Total())
+	assert.InDelta(t, 80.0, c.Precision(), 1e-9)
+	assert.InDelta(t, 80.0, c.Recall(), 1e-9)
+	assert.InDelta(t, 80.0, c.F1(), 1e-9)
+	assert.InDelta(t, 80.0, c.Accuracy(), 1e-9)
+	assert.InDelta(t, 20.0, c.FPR(), 1e-9)
+
+	var empty ConfusionMatrix
+	assert.Zero(t, empty.Precision())
+	assert.Zero(t, empty.F1())
+	assert.Zero(t, empty.Accuracy())
+}
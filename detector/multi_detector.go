@@ -2,13 +2,16 @@ package detector
 
 import (
 	"context"
+	"errors"
 	"math"
+	"strings"
 )
 
 // MultiDetector combines multiple detectors and aggregates their results,
 type MultiDetector struct {
 	detectors []Detector
 	config    Config
+	shadow    *shadowRunner // LLMShadow only
 }
 
 // New creates a new MultiDetector with the given configuration options.
@@ -60,91 +63,173 @@ func New(opts ...Option) *MultiDetector {
 		md.detectors = append(md.detectors, NewDelimiterDetector(cfg.DelimiterMode))
 	}
 
+	if cfg.LLMJudge != nil && cfg.LLMRunMode == LLMShadow {
+		md.shadow = newShadowRunner(cfg)
+	}
+
 	return md
 }
 
+// ShadowStats returns LLMShadow counters. Zero unless the run mode is LLMShadow.
+func (md *MultiDetector) ShadowStats() ShadowStats {
+	if md.shadow == nil {
+		return ShadowStats{}
+	}
+	return md.shadow.getStats()
+}
+
+// Close stops LLMShadow workers after the queued checks finish. Detect keeps
+// working afterwards but no longer queues shadow checks. No-op for other modes.
+func (md *MultiDetector) Close() {
+	if md.shadow != nil {
+		md.shadow.close()
+	}
+}
+
 // Detect runs all enabled detectors and combines their results.
-// Risk score is computed by computeWeightedScore (see scoring.go).
+// Risk score is computed by computeWeightedScore (see scoring.go), or by the
+// trained classifier when one is configured with WithClassifier.
 // The input is considered unsafe if the final risk score >= threshold.
+// If a stage did not finish, Result.Incomplete is set and the verdict follows
+// the FailurePolicy; use DetectE to get that as an error.
 func (md *MultiDetector) Detect(ctx context.Context, input string) Result {
-	if md.config.MaxInputLength > 0 && len(input) > md.config.MaxInputLength {
-		input = input[:md.config.MaxInputLength]
+	result, _ := md.DetectE(ctx, input)
+	return result
+}
+
+// DetectE is Detect that also returns an error when the check did not finish,
+// so callers can tell "safe" from "not fully checked". The returned Result is
+// still usable: it holds whatever finished, with Safe set by the FailurePolicy.
+func (md *MultiDetector) DetectE(ctx context.Context, input string) (Result, error) {
+	result := md.detect(ctx, input)
+	if !result.Incomplete {
+		return result, nil
 	}
 
-	allPatterns := make([]DetectedPattern, 0)
-	maxScore := 0.0
-	maxConfidence := 0.0
-	detectorsTriggered := 0
-
-	// Run each detector
-	for _, d := range md.detectors {
-		select {
-		case <-ctx.Done():
-			return Result{
-				Safe:             true,
-				RiskScore:        0.0,
-				Confidence:       0.0,
-				DetectedPatterns: nil,
-			}
-		default:
-		}
+	errs := make([]error, len(result.Errors))
+	for i, e := range result.Errors {
+		errs[i] = e
+	}
+	return result, errors.Join(errs...)
+}
 
-		result := d.Detect(ctx, input)
+func (md *MultiDetector) detect(ctx context.Context, input string) Result {
+	c, aborted := md.patternStage(ctx, input)
+	if aborted != nil {
+		return *aborted
+	}
+	if !c.runLLM {
+		return md.finish(c, nil)
+	}
+
+	llmCtx := WithPatternVerdict(ctx, PatternVerdict{
+		RiskScore: c.score,
+		Unsafe:    c.score >= md.config.Threshold,
+	})
+	llmResult := newStageDetector(md.config).Detect(llmCtx, c.input)
+	return md.finish(c, &llmResult)
+}
 
-		// Round pattern scores to avoid floating point precision issues
-		for i := range result.DetectedPatterns {
-			result.DetectedPatterns[i].Score = round(result.DetectedPatterns[i].Score, 2)
+// DetectBatch is Detect for many inputs, returning results in input order.
+// When the LLM judge implements BatchJudge, the inputs that need the LLM are
+// sent in one JudgeBatch call instead of one request each; batch calls do not
+// carry a PatternVerdict. Each request gets the same LLM stage timeout as a
+// Detect call. GenericLLMJudge and ClassifierJudge batch, as do
+// CachingJudge, BudgetJudge and FailoverJudge when a judge they wrap does.
+// Other judges, including EnsembleJudge and CascadeJudge, are called per
+// input as in Detect.
+func (md *MultiDetector) DetectBatch(ctx context.Context, inputs []string) []Result {
+	results := make([]Result, len(inputs))
+	if !batches(md.config.LLMJudge) {
+		for i, input := range inputs {
+			results[i] = md.Detect(ctx, input)
 		}
+		return results
+	}
 
-		allPatterns = append(allPatterns, result.DetectedPatterns...)
+	var pending []check
+	var pendingIdx []int
+	for i, input := range inputs {
+		c, aborted := md.patternStage(ctx, input)
+		switch {
+		case aborted != nil:
+			results[i] = *aborted
+		case c.runLLM:
+			pending = append(pending, c)
+			pendingIdx = append(pendingIdx, i)
+		default:
+			results[i] = md.finish(c, nil)
+		}
+	}
+	if len(pending) == 0 {
+		return results
+	}
 
-		if result.RiskScore > maxScore {
-			maxScore = result.RiskScore
+	size := batchLimit(md.config.LLMJudge)
+	if size == 0 {
+		size = len(pending)
+	}
+	timeout := stageTimeout(md.config)
+	for start := 0; start < len(pending); start += size {
+		end := minInt(start+size, len(pending))
+		texts := make([]string, end-start)
+		for k, c := range pending[start:end] {
+			texts[k] = c.input
 		}
 
-		// Track highest confidence from detectors that triggered
-		if result.RiskScore > 0 {
-			if result.Confidence > maxConfidence {
-				maxConfidence = result.Confidence
-			}
-			detectorsTriggered++
+		chunkCtx, cancel := context.WithTimeout(ctx, timeout)
+		llmResults, errs := judgeEach(chunkCtx, md.config.LLMJudge, texts)
+		cancel()
+		for k, c := range pending[start:end] {
+			stage := llmStageResult(llmResults[k], errs[k])
+			results[pendingIdx[start+k]] = md.finish(c, &stage)
 		}
 	}
+	return results
+}
 
-	// Run detectors on decoded variants of the input (hex bytes, escape sequences, HTML entities).
-	// Patterns from decoded candidates accumulate into allPatterns — scoring deduplicates by category.
-	for _, candidate := range Preprocess(input)[1:] {
-		for _, d := range md.detectors {
-			select {
-			case <-ctx.Done():
-				return Result{Safe: true, RiskScore: 0.0, Confidence: 0.0, DetectedPatterns: nil}
-			default:
-			}
+// check is one input between the pattern stage and the final verdict.
+type check struct {
+	input      string
+	pass       patternPass
+	score      float64 // pattern-based score before calibration
+	confidence float64
+	path       []string
+	runLLM     bool
+}
 
-			result := d.Detect(ctx, candidate)
-			for i := range result.DetectedPatterns {
-				result.DetectedPatterns[i].Score = round(result.DetectedPatterns[i].Score, 2)
-			}
-			allPatterns = append(allPatterns, result.DetectedPatterns...)
-			if result.RiskScore > maxScore {
-				maxScore = result.RiskScore
-			}
-			if result.RiskScore > 0 {
-				if result.Confidence > maxConfidence {
-					maxConfidence = result.Confidence
-				}
-				detectorsTriggered++
-			}
+// patternStage runs the pattern detectors and decides whether the LLM should run.
+// It returns a finished Result instead if the context ended first.
+func (md *MultiDetector) patternStage(ctx context.Context, input string) (check, *Result) {
+	if md.config.MaxInputLength > 0 && len(input) > md.config.MaxInputLength {
+		input = input[:md.config.MaxInputLength]
+	}
+
+	pass, ok := md.runPatternDetectors(ctx, input)
+	if !ok {
+		var path []string
+		if md.config.FailurePolicy == FailClosed {
+			path = []string{DecisionFailClosed}
+		}
+		return check{}, &Result{
+			DecisionPath:     path,
+			Safe:             md.config.FailurePolicy == FailOpen,
+			RiskScore:        0.0,
+			Confidence:       0.0,
+			DetectedPatterns: nil,
+			Incomplete:       true,
+			Errors:           []StageError{{Stage: StagePatterns, Err: ctx.Err()}},
 		}
 	}
 
-	finalScore := computeWeightedScore(allPatterns)
+	finalScore := md.score(input, pass.patterns)
+	path := []string{DecisionPatterns}
 
 	finalConfidence := 0.0
-	if detectorsTriggered > 0 {
+	if pass.triggered > 0 {
 		// Use max confidence from detectors, with bonus if multiple agree
-		finalConfidence = maxConfidence
-		if detectorsTriggered > 1 {
+		finalConfidence = pass.maxConfidence
+		if pass.triggered > 1 {
 			// Multiple detectors agree - boost confidence slightly
 			finalConfidence = min(finalConfidence+0.05, 1.0)
 		}
@@ -164,20 +249,53 @@ func (md *MultiDetector) Detect(ctx context.Context, input string) Result {
 		case LLMAlways:
 			shouldRunLLM = true
 		case LLMConditional:
-			// Run if pattern-based detectors are uncertain (0.5-0.7)
-			shouldRunLLM = finalScore >= 0.5 && finalScore <= 0.7
+			// Run if pattern-based detectors are uncertain
+			shouldRunLLM = finalScore >= md.config.LLMBandLow && finalScore <= md.config.LLMBandHigh
 		case LLMFallback:
 			// Run if pattern-based detectors say safe
 			shouldRunLLM = finalScore < md.config.Threshold
+		case LLMVerify:
+			// Run if pattern-based detectors say unsafe
+			shouldRunLLM = finalScore >= md.config.Threshold
+		}
+		// LLMShadow judges in the background once the result is ready
+		if !shouldRunLLM && md.shadow == nil {
+			path = append(path, DecisionLLMSkipped)
 		}
 	}
 
-	// Run LLM detector if needed
+	return check{
+		input:      input,
+		pass:       pass,
+		score:      finalScore,
+		confidence: finalConfidence,
+		path:       path,
+		runLLM:     shouldRunLLM,
+	}, nil
+}
+
+// finish merges the LLM stage result (nil if the LLM did not run) into the
+// pattern-based verdict, calibrates it and applies the failure policy.
+func (md *MultiDetector) finish(c check, llmResult *Result) Result {
+	allPatterns := c.pass.patterns
+	maxConfidence := c.pass.maxConfidence
+	detectorsTriggered := c.pass.triggered
+	finalScore := c.score
+	finalConfidence := c.confidence
+	path := c.path
+	input := c.input
+
 	var llmResultData *LLMResult
-	if shouldRunLLM {
-		llmDetector := NewLLMDetector(md.config.LLMJudge)
-		llmResult := llmDetector.Detect(ctx, input)
+	var stageErrors []StageError
+	var overridden []DetectedPattern
+	if llmResult != nil {
 		llmResultData = llmResult.LLMResult
+		stageErrors = llmResult.Errors
+		if len(stageErrors) > 0 {
+			path = append(path, DecisionLLMFailed)
+		} else {
+			path = append(path, DecisionLLM)
+		}
 
 		// Round LLM pattern scores
 		for i := range llmResult.DetectedPatterns {
@@ -186,11 +304,7 @@ func (md *MultiDetector) Detect(ctx context.Context, input string) Result {
 
 		allPatterns = append(allPatterns, llmResult.DetectedPatterns...)
 
-		if llmResult.RiskScore > maxScore {
-			maxScore = llmResult.RiskScore
-		}
-
-		finalScore = computeWeightedScore(allPatterns)
+		finalScore = md.score(input, allPatterns)
 
 		// Recalculate confidence including LLM result
 		if llmResult.RiskScore > 0 {
@@ -210,15 +324,141 @@ func (md *MultiDetector) Detect(ctx context.Context, input string) Result {
 			// Still no detections even after LLM check = very high confidence it's safe
 			finalConfidence = 1.0
 		}
+
+		if md.config.LLMRunMode == LLMVerify && len(stageErrors) == 0 {
+			if llmResultData != nil && !llmResultData.IsAttack && llmResultData.Confidence >= md.config.LLMVerifyConfidence {
+				allPatterns, overridden = md.splitOverridable(allPatterns)
+			}
+			if len(overridden) > 0 {
+				path = append(path, DecisionLLMOverride)
+				finalScore = md.score(input, allPatterns)
+				finalConfidence = llmResultData.Confidence
+			} else {
+				path = append(path, DecisionLLMUpheld)
+			}
+		}
+	}
+
+	// Calibrate last so the LLM run decision above still sees the raw score
+	if md.config.Calibration != nil {
+		finalScore = md.config.Calibration.Apply(finalScore)
+		path = append(path, DecisionCalibrated)
+	}
+
+	safe := finalScore < md.config.Threshold
+	if len(stageErrors) > 0 && (md.config.FailurePolicy == FailClosed || stageFailsClosed(stageErrors)) {
+		safe = false
+		path = append(path, DecisionFailClosed)
 	}
 
-	return Result{
-		Safe:             finalScore < md.config.Threshold,
+	result := Result{
+		Safe:             safe,
 		RiskScore:        round(finalScore, 2),
 		Confidence:       round(finalConfidence, 2),
 		DetectedPatterns: allPatterns,
 		LLMResult:        llmResultData,
+		Incomplete:       len(stageErrors) > 0,
+		Errors:           stageErrors,
+		DecisionPath:     path,
+		Overridden:       overridden,
+	}
+
+	if md.shadow != nil {
+		if md.shadow.submit(input, result) {
+			result.DecisionPath = append(result.DecisionPath, DecisionLLMShadow)
+		} else {
+			result.DecisionPath = append(result.DecisionPath, DecisionLLMSkipped)
+		}
+	}
+	return result
+}
+
+// splitOverridable separates pattern detections LLMVerify may clear from those
+// that stand. LLM patterns always stand.
+func (md *MultiDetector) splitOverridable(patterns []DetectedPattern) (kept, overridden []DetectedPattern) {
+	kept = make([]DetectedPattern, 0, len(patterns))
+	for _, p := range patterns {
+		if !strings.HasPrefix(p.Type, "llm_") && md.overridable(p.Type) {
+			overridden = append(overridden, p)
+		} else {
+			kept = append(kept, p)
+		}
+	}
+	return kept, overridden
+}
+
+func (md *MultiDetector) overridable(patternType string) bool {
+	if len(md.config.LLMOverridable) == 0 {
+		return true
+	}
+	for _, c := range md.config.LLMOverridable {
+		if strings.HasPrefix(patternType, c) {
+			return true
+		}
+	}
+	return false
+}
+
+// patternPass holds the combined output of the pattern-based detectors.
+type patternPass struct {
+	patterns      []DetectedPattern
+	maxConfidence float64
+	triggered     int
+}
+
+// runPatternDetectors runs every enabled detector on the input and on its decoded
+// variants (hex bytes, escape sequences, HTML entities).
+// Returns false if the context was cancelled before all detectors ran.
+func (md *MultiDetector) runPatternDetectors(ctx context.Context, input string) (patternPass, bool) {
+	pass := patternPass{patterns: make([]DetectedPattern, 0)}
+
+	// Patterns from decoded candidates accumulate into pass.patterns — scoring deduplicates by category.
+	for _, candidate := range Preprocess(input) {
+		for _, d := range md.detectors {
+			select {
+			case <-ctx.Done():
+				return pass, false
+			default:
+			}
+
+			result := d.Detect(ctx, candidate)
+
+			// Round pattern scores to avoid floating point precision issues
+			for i := range result.DetectedPatterns {
+				result.DetectedPatterns[i].Score = round(result.DetectedPatterns[i].Score, 2)
+			}
+
+			pass.patterns = append(pass.patterns, result.DetectedPatterns...)
+
+			// Track highest confidence from detectors that triggered
+			if result.RiskScore > 0 {
+				if result.Confidence > pass.maxConfidence {
+					pass.maxConfidence = result.Confidence
+				}
+				pass.triggered++
+			}
+		}
+	}
+
+	// A detector may have bailed out on cancellation after the check above
+	return pass, ctx.Err() == nil
+}
+
+// score computes the risk score for the collected patterns.
+// Without a trained model this is computeWeightedScore. With one, the model
+// scores the pattern-based features and LLM patterns can only raise the result.
+func (md *MultiDetector) score(input string, patterns []DetectedPattern) float64 {
+	if md.config.Model == nil {
+		return computeWeightedScore(patterns)
+	}
+
+	score := md.config.Model.Predict(extractFeatures(input, patterns))
+	for _, p := range patterns {
+		if strings.HasPrefix(p.Type, "llm_") && p.Score > score {
+			score = p.Score
+		}
 	}
+	return round(score, 2)
 }
 
 func min(a, b float64) float64 {
@@ -1,5 +1,7 @@
 package detector
 
+import "encoding/json"
+
 // Result represents the detection result from analyzing an input.
 type Result struct {
 	Safe             bool    // true = safe, false = malicious
@@ -7,6 +9,59 @@ type Result struct {
 	Confidence       float64 // 0.0 - 1.0 confidence score
 	DetectedPatterns []DetectedPattern
 	LLMResult        *LLMResult // Optional LLM-specific data (only set when using LLM detection)
+
+	// Incomplete is true when a stage did not finish (cancelled context, LLM error).
+	// Safe then follows the configured FailurePolicy rather than the score.
+	Incomplete bool
+	Errors     []StageError
+
+	// DecisionPath lists the steps that produced the verdict, e.g.
+	// ["patterns", "llm", "llm_override"]. See the Decision constants.
+	DecisionPath []string
+
+	// Overridden holds pattern detections the LLM cleared (LLMVerify).
+	Overridden []DetectedPattern
+}
+
+// Steps recorded in Result.DecisionPath.
+const (
+	DecisionPatterns    = "patterns"     // pattern detectors scored the input
+	DecisionLLMSkipped  = "llm_skipped"  // a judge is configured but the run mode did not call it
+	DecisionLLM         = "llm"          // the judge answered
+	DecisionLLMFailed   = "llm_failed"   // the judge returned an error
+	DecisionLLMOverride = "llm_override" // LLMVerify cleared pattern detections
+	DecisionLLMUpheld   = "llm_upheld"   // LLMVerify kept the pattern verdict
+	DecisionLLMShadow   = "llm_shadow"   // queued for background judging (LLMShadow)
+	DecisionCalibrated  = "calibrated"   // the score went through the calibration mapping
+	DecisionFailClosed  = "fail_closed"  // a stage did not finish and FailClosed made the input unsafe
+)
+
+// Stages reported in StageError.
+const (
+	StagePatterns = "patterns"
+	StageLLM      = "llm"
+)
+
+// StageError records a detection stage that did not finish.
+type StageError struct {
+	Stage string // StagePatterns or StageLLM
+	Err   error
+}
+
+func (e StageError) Error() string {
+	return e.Stage + ": " + e.Err.Error()
+}
+
+func (e StageError) Unwrap() error {
+	return e.Err
+}
+
+// MarshalJSON writes the error message, which the error value itself would lose.
+func (e StageError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Stage string
+		Error string
+	}{e.Stage, e.Err.Error()})
 }
 
 // DetectedPattern contains information about a specific pattern detected in the input.
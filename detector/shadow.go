@@ -0,0 +1,128 @@
+package detector
+
+import (
+	"context"
+	"math/rand"
+	"slices"
+	"sync"
+)
+
+// ShadowReport describes an input on which the judge disagreed with the
+// verdict LLMShadow returned.
+type ShadowReport struct {
+	Input     string
+	Result    Result    // what Detect returned
+	LLMResult LLMResult // what the judge said
+}
+
+// ShadowStats counts LLMShadow activity since the detector was created.
+type ShadowStats struct {
+	Queued        int // sampled inputs accepted into the queue
+	Dropped       int // sampled inputs dropped because the queue was full
+	Judged        int // inputs the judge answered
+	Disagreements int // answers that disagreed with the returned verdict
+	Errors        int // judge calls that failed
+}
+
+type shadowJob struct {
+	input  string
+	result Result
+}
+
+// shadowRunner judges sampled inputs on a fixed pool of workers fed by a bounded queue.
+type shadowRunner struct {
+	detector *LLMDetector
+	rate     float64
+	handler  func(ShadowReport)
+	queue    chan shadowJob
+	wg       sync.WaitGroup
+
+	mu     sync.Mutex
+	closed bool
+	stats  ShadowStats
+}
+
+func newShadowRunner(cfg Config) *shadowRunner {
+	s := &shadowRunner{
+		detector: newStageDetector(cfg),
+		rate:     cfg.ShadowSampleRate,
+		handler:  cfg.ShadowHandler,
+		queue:    make(chan shadowJob, cfg.ShadowQueueSize),
+	}
+	for i := 0; i < cfg.ShadowWorkers; i++ {
+		s.wg.Add(1)
+		go s.work()
+	}
+	return s
+}
+
+// submit queues a sampled input without blocking. Returns false if the input
+// was not sampled, the queue was full or the runner is closed.
+func (s *shadowRunner) submit(input string, result Result) bool {
+	if s.rate < 1.0 && rand.Float64() >= s.rate {
+		return false
+	}
+
+	result.DetectedPatterns = slices.Clone(result.DetectedPatterns)
+	result.DecisionPath = slices.Clone(result.DecisionPath)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	select {
+	case s.queue <- shadowJob{input: input, result: result}:
+		s.stats.Queued++
+		return true
+	default:
+		s.stats.Dropped++
+		return false
+	}
+}
+
+func (s *shadowRunner) work() {
+	defer s.wg.Done()
+	for job := range s.queue {
+		// Detached from the request: it has usually returned by now
+		ctx := WithPatternVerdict(context.Background(), PatternVerdict{
+			RiskScore: job.result.RiskScore,
+			Unsafe:    !job.result.Safe,
+		})
+		llm := s.detector.Detect(ctx, job.input)
+
+		s.mu.Lock()
+		if llm.LLMResult == nil {
+			s.stats.Errors++
+			s.mu.Unlock()
+			continue
+		}
+		s.stats.Judged++
+		disagree := llm.LLMResult.IsAttack == job.result.Safe
+		if disagree {
+			s.stats.Disagreements++
+		}
+		s.mu.Unlock()
+
+		if disagree && s.handler != nil {
+			s.handler(ShadowReport{Input: job.input, Result: job.result, LLMResult: *llm.LLMResult})
+		}
+	}
+}
+
+func (s *shadowRunner) getStats() ShadowStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stats
+}
+
+// close stops accepting work and waits for queued checks to finish.
+func (s *shadowRunner) close() {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.queue)
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
@@ -0,0 +1,86 @@
+package detector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultiDetector_LLMShadow_ReportsDisagreements(t *testing.T) {
+	reports := make(chan ShadowReport, 1)
+	guard := New(
+		WithLLM(attackVote(0.9, "prompt_leak"), LLMShadow),
+		WithShadowHandler(func(r ShadowReport) { reports <- r }),
+	)
+	defer guard.Close()
+
+	result := guard.Detect(context.Background(), "What is the capital of France?")
+	assert.True(t, result.Safe, "the judge never changes the returned verdict")
+	assert.Nil(t, result.LLMResult)
+	assert.Equal(t, []string{DecisionPatterns, DecisionLLMShadow}, result.DecisionPath)
+
+	select {
+	case r := <-reports:
+		assert.Equal(t, "What is the capital of France?", r.Input)
+		assert.True(t, r.Result.Safe)
+		assert.True(t, r.LLMResult.IsAttack)
+	case <-time.After(time.Second):
+		t.Fatal("no disagreement reported")
+	}
+
+	guard.Close()
+	stats := guard.ShadowStats()
+	assert.Equal(t, ShadowStats{Queued: 1, Judged: 1, Disagreements: 1}, stats)
+}
+
+func TestMultiDetector_LLMShadow_Agreement(t *testing.T) {
+	called := false
+	guard := New(
+		WithLLM(safeVote(0.9), LLMShadow),
+		WithShadowHandler(func(ShadowReport) { called = true }),
+	)
+
+	guard.Detect(context.Background(), "What is the capital of France?")
+	guard.Close()
+
+	assert.False(t, called)
+	assert.Equal(t, ShadowStats{Queued: 1, Judged: 1}, guard.ShadowStats())
+}
+
+func TestMultiDetector_LLMShadow_Sampling(t *testing.T) {
+	judge := &countingJudge{}
+	guard := New(WithLLM(judge, LLMShadow), WithShadowSampling(0))
+
+	for i := 0; i < 10; i++ {
+		result := guard.Detect(context.Background(), "hello")
+		assert.Equal(t, []string{DecisionPatterns, DecisionLLMSkipped}, result.DecisionPath)
+	}
+	guard.Close()
+	assert.Equal(t, int32(0), judge.calls.Load())
+}
+
+func TestMultiDetector_LLMShadow_DropsWhenFull(t *testing.T) {
+	judge := &countingJudge{gate: make(chan struct{})}
+	guard := New(WithLLM(judge, LLMShadow), WithShadowQueue(2, 1))
+
+	start := time.Now()
+	for i := 0; i < 10; i++ {
+		guard.Detect(context.Background(), "hello")
+	}
+	assert.Less(t, time.Since(start), time.Second, "Detect must not wait for the judge")
+
+	close(judge.gate)
+	guard.Close()
+
+	stats := guard.ShadowStats()
+	require.Equal(t, 10, stats.Queued+stats.Dropped)
+	assert.LessOrEqual(t, stats.Queued, 3, "one in flight plus a queue of two")
+	assert.Equal(t, stats.Queued, stats.Judged)
+
+	// Closed detectors still detect but stop queueing
+	result := guard.Detect(context.Background(), "hello")
+	assert.Contains(t, result.DecisionPath, DecisionLLMSkipped)
+}
@@ -13,18 +13,47 @@ import (
 // Judges:
 //   - NewOpenAIJudge(apiKey, model)
 //   - NewOpenRouterJudge(apiKey, model)
+//   - NewAnthropicJudge(apiKey, model)
+//   - NewAzureOpenAIJudge(endpoint, apiKey, deployment, apiVersion)
+//   - NewGeminiJudge(apiKey, model)
 //   - NewOllamaJudge(model)
 //   - NewOllamaJudgeWithEndpoint(endpoint, model)
+//   - NewOllamaNativeJudge(ctx, endpoint, model) - native /api/chat, checks the model is pulled
+//   - NewClassifierJudge(endpoint)  - Prompt Guard/DeBERTa behind TEI /predict or KServe v2
 //
 // Run modes:
 //   - LLMAlways       - Check every input
-//   - LLMConditional  - Only when pattern score is 0.5-0.7
+//   - LLMConditional  - Only when pattern score is 0.5-0.7 (WithLLMBand to change)
 //   - LLMFallback     - Only when patterns say safe
+//   - LLMVerify       - Only when patterns say unsafe; can clear false positives
+//   - LLMShadow       - In the background on sampled inputs, reports disagreements
 //
 // Judge options:
 //   - WithOutputFormat(format)    - LLMStructured for detailed reasoning
 //   - WithSystemPrompt(prompt)    - Custom detection prompt
 //   - WithLLMTimeout(duration)    - Custom timeout
+//   - WithPromptHardening(bool)   - Nonce-bound verdicts (default on)
+//   - WithInputMarking(mode)      - InputDatamark (default), InputBase64, InputRaw
+//   - WithJSONSchema(bool)        - Schema-constrained structured output (default on for OpenAI, Azure, Gemini)
+//   - WithApplicationContext(d, topics, forbidden) - App purpose; flags goal hijacking
+//   - WithFewShotExamples(ex)     - Labeled examples shown as prior chat turns
+//   - WithExampleRetriever(r, k)  - k most similar examples per input (LoadExampleRetriever)
+//   - WithBatchSize(n)            - Inputs per JudgeBatch request (default 20, see DetectBatch)
+//   - WithTemperature(t)          - Sampling temperature (default 1 for OpenAI-compatible APIs)
+//   - WithLogprobConfidence()     - Confidence from SAFE/ATTACK token probabilities
+//   - WithRetries(n)              - Retries for 429/5xx (default 2)
+//   - WithCircuitBreaker(n, d)    - Fail fast after n failures for d
+//   - WithHTTPClient(c), WithTransport(rt) - Proxy, mTLS, test transports
+//   - WithHeaders(h)              - Extra static headers (OpenRouter, OpenAI organization)
+//   - WithRequestHook(fn)         - Sign or modify each request before it is sent
+//   - WithKeepAlive(d), WithNumCtx(n) - Native Ollama judge only
+//
+// Wrappers:
+//   - NewFailoverJudge(judges...) - First judge that answers wins
+//   - NewCachingJudge(judge, ...) - LRU/disk cache, collapses duplicate calls
+//   - NewEnsembleJudge(members)   - Parallel vote across judges
+//   - NewCascadeJudge(stages...)  - Escalate from cheap to expensive models
+//   - NewBudgetJudge(judge, ...)  - Rate limit, concurrency cap, daily token/cost budget
 
 func main() {
 	ctx := context.Background()
@@ -43,6 +72,10 @@ func main() {
 	judge = detector.NewOpenRouterJudge("sk-or-v1-...", "anthropic/claude-sonnet-4.5")
 	guard = detector.New(detector.WithLLM(judge, detector.LLMConditional))
 
+	// Anthropic Messages API directly
+	judge = detector.NewAnthropicJudge("sk-ant-...", "claude-sonnet-4-5")
+	guard = detector.New(detector.WithLLM(judge, detector.LLMConditional))
+
 	// Ollama for local models
 	// Warmup pre-loads the model in the background to avoid cold start latency on the first real call
 	judge = detector.NewOllamaJudge("llama3.1:8b")